@@ -98,8 +98,16 @@ func (b *ByteBuffer) WriteLength(value int) {
 	b.WriteVarInt32(int32(value))
 }
 
+// ReadLength reads a length of bytes or elements written by `WriteLength`. Every element takes at least one
+// byte, so a length larger than the remaining bytes is reported as buffer underflow before anything is
+// allocated for it.
 func (b *ByteBuffer) ReadLength() int {
-	return int(b.ReadVarInt32())
+	length := int(b.ReadVarInt32())
+	if length < 0 {
+		panic(invalidDataError(b, "negative length %d", length))
+	}
+	b.checkReadable(length)
+	return length
 }
 
 func (b *ByteBuffer) WriteInt64(value int64) {
@@ -136,7 +144,16 @@ func (b *ByteBuffer) WriteBinary(p []byte) {
 	b.writerIndex += len(p)
 }
 
+// checkReadable panics with a *BufferUnderflowError if there are less than `n` bytes to read. The panic is
+// recovered by `Fury.Deserialize` and returned as an error.
+func (b *ByteBuffer) checkReadable(n int) {
+	if n < 0 || b.readerIndex < 0 || n > len(b.data)-b.readerIndex {
+		panic(&BufferUnderflowError{Offset: b.readerIndex, Size: n, Remaining: len(b.data) - b.readerIndex})
+	}
+}
+
 func (b *ByteBuffer) ReadBool() bool {
+	b.checkReadable(1)
 	v := b.data[b.readerIndex]
 	b.readerIndex++
 	if v == 0 {
@@ -147,36 +164,45 @@ func (b *ByteBuffer) ReadBool() bool {
 }
 
 func (b *ByteBuffer) ReadByte_() byte {
+	b.checkReadable(1)
 	v := b.data[b.readerIndex]
 	b.readerIndex++
 	return v
 }
 
+// ReadByte implements io.ByteReader, io.EOF is returned if no byte is available.
 func (b *ByteBuffer) ReadByte() (byte, error) {
+	if b.readerIndex < 0 || b.readerIndex >= len(b.data) {
+		return 0, io.EOF
+	}
 	v := b.data[b.readerIndex]
 	b.readerIndex++
 	return v, nil
 }
 
 func (b *ByteBuffer) ReadInt8() int8 {
+	b.checkReadable(1)
 	i := int8(b.data[b.readerIndex])
 	b.readerIndex += 1
 	return i
 }
 
 func (b *ByteBuffer) ReadInt16() int16 {
+	b.checkReadable(2)
 	i := int16(binary.LittleEndian.Uint16(b.data[b.readerIndex:]))
 	b.readerIndex += 2
 	return i
 }
 
 func (b *ByteBuffer) ReadUint32() uint32 {
+	b.checkReadable(4)
 	i := binary.LittleEndian.Uint32(b.data[b.readerIndex:])
 	b.readerIndex += 4
 	return i
 }
 
 func (b *ByteBuffer) ReadUint64() uint64 {
+	b.checkReadable(8)
 	i := binary.LittleEndian.Uint64(b.data[b.readerIndex:])
 	b.readerIndex += 8
 	return i
@@ -198,13 +224,22 @@ func (b *ByteBuffer) ReadFloat64() float64 {
 	return Float64frombits(b.ReadUint64())
 }
 
+// Read implements io.Reader, io.EOF is returned if no byte is available.
 func (b *ByteBuffer) Read(p []byte) (n int, err error) {
+	if len(p) == 0 {
+		return 0, nil
+	}
+	if b.readerIndex < 0 || b.readerIndex >= len(b.data) {
+		return 0, io.EOF
+	}
 	copied := copy(p, b.data[b.readerIndex:])
 	b.readerIndex += copied
 	return copied, nil
 }
 
+// ReadBinary returns the next `length` bytes, which shares the underlying data with the buffer.
 func (b *ByteBuffer) ReadBinary(length int) []byte {
+	b.checkReadable(length)
 	v := b.data[b.readerIndex : b.readerIndex+length]
 	b.readerIndex += length
 	return v
@@ -249,7 +284,8 @@ func (b *ByteBuffer) PutInt32(index int, value int32) {
 	binary.LittleEndian.PutUint32(b.data[index:], uint32(value))
 }
 
-// WriteVarInt32 WriteVarUint writes a 1-5 byte int, returns the number of bytes written.
+// WriteVarInt32 writes `value` as an unsigned varint32 of 1-5 bytes, returns the number of bytes written.
+// Negative values always take 5 bytes, use `WriteSignedVarInt32` for values which may be negative.
 func (b *ByteBuffer) WriteVarInt32(value int32) int8 {
 	if value>>7 == 0 {
 		b.grow(1)
@@ -293,6 +329,10 @@ func (b *ByteBuffer) WriteVarInt32(value int32) int8 {
 
 // ReadVarInt32 reads the 1-5 byte int part of a varint.
 func (b *ByteBuffer) ReadVarInt32() int32 {
+	if b.readerIndex < 0 || len(b.data)-b.readerIndex < 5 {
+		// the varint may end at the end of buffer, read it byte by byte with bounds check.
+		return b.readVarInt32Slow()
+	}
 	readerIndex := b.readerIndex
 	byte_ := int32(b.data[readerIndex])
 	readerIndex++
@@ -321,6 +361,96 @@ func (b *ByteBuffer) ReadVarInt32() int32 {
 	return result
 }
 
+func (b *ByteBuffer) readVarInt32Slow() int32 {
+	var result int32
+	for i := 0; ; i++ {
+		if b.readerIndex < 0 || b.readerIndex+i >= len(b.data) {
+			panic(&BufferUnderflowError{Offset: b.readerIndex, Size: i + 1, Remaining: len(b.data) - b.readerIndex})
+		}
+		byte_ := int32(b.data[b.readerIndex+i])
+		result |= (byte_ & 0x7F) << (7 * uint(i))
+		if byte_&0x80 == 0 || i == 4 {
+			b.readerIndex += i + 1
+			return result
+		}
+	}
+}
+
+// WriteVarUint32 writes an unsigned varint32 of 1-5 bytes, returns the number of bytes written.
+func (b *ByteBuffer) WriteVarUint32(value uint32) int8 {
+	return b.WriteVarInt32(int32(value))
+}
+
+// ReadVarUint32 reads an unsigned varint32 written by `WriteVarUint32`.
+func (b *ByteBuffer) ReadVarUint32() uint32 {
+	return uint32(b.ReadVarInt32())
+}
+
+// WriteSignedVarInt32 writes a signed varint32 of 1-5 bytes, which is the zigzag encoding of `value` written
+// as an unsigned varint32, so small negative values take few bytes too. Returns the number of bytes written.
+func (b *ByteBuffer) WriteSignedVarInt32(value int32) int8 {
+	return b.WriteVarInt32((value << 1) ^ (value >> 31))
+}
+
+// ReadSignedVarInt32 reads a signed varint32 written by `WriteSignedVarInt32`.
+func (b *ByteBuffer) ReadSignedVarInt32() int32 {
+	v := uint32(b.ReadVarInt32())
+	return int32(v>>1) ^ -int32(v&1)
+}
+
+// WriteVarUint64 writes an unsigned varint64 of 1-9 bytes in PVL(Progressive Variable-length Long) encoding:
+// the first 8 bytes hold 7 bits each with the highest bit indicating whether there is a next byte, and the 9th
+// byte holds the remaining 8 bits. Returns the number of bytes written.
+func (b *ByteBuffer) WriteVarUint64(value uint64) int8 {
+	b.grow(9)
+	start := b.writerIndex
+	index := start
+	for ; index-start < 8; index++ {
+		if value>>7 == 0 {
+			b.data[index] = byte(value)
+			b.writerIndex = index + 1
+			return int8(b.writerIndex - start)
+		}
+		b.data[index] = byte(value&0x7F) | 0x80
+		value >>= 7
+	}
+	b.data[index] = byte(value)
+	b.writerIndex = index + 1
+	return 9
+}
+
+// ReadVarUint64 reads an unsigned varint64 written by `WriteVarUint64`.
+func (b *ByteBuffer) ReadVarUint64() uint64 {
+	var result uint64
+	for i := 0; ; i++ {
+		if b.readerIndex < 0 || b.readerIndex+i >= len(b.data) {
+			panic(&BufferUnderflowError{Offset: b.readerIndex, Size: i + 1, Remaining: len(b.data) - b.readerIndex})
+		}
+		byte_ := uint64(b.data[b.readerIndex+i])
+		if i == 8 {
+			result |= byte_ << 56
+		} else {
+			result |= (byte_ & 0x7F) << (7 * uint(i))
+		}
+		if byte_&0x80 == 0 || i == 8 {
+			b.readerIndex += i + 1
+			return result
+		}
+	}
+}
+
+// WriteSignedVarInt64 writes a signed varint64 of 1-9 bytes, which is the zigzag encoding of `value` written
+// as an unsigned varint64. Returns the number of bytes written.
+func (b *ByteBuffer) WriteSignedVarInt64(value int64) int8 {
+	return b.WriteVarUint64(uint64((value << 1) ^ (value >> 63)))
+}
+
+// ReadSignedVarInt64 reads a signed varint64 written by `WriteSignedVarInt64`.
+func (b *ByteBuffer) ReadSignedVarInt64() int64 {
+	v := b.ReadVarUint64()
+	return int64(v>>1) ^ -int64(v&1)
+}
+
 type BufferObject interface {
 	TotalBytes() int
 	WriteTo(buf *ByteBuffer)
@@ -18,7 +18,9 @@
 package fury
 
 import (
+	"errors"
 	"github.com/stretchr/testify/require"
+	"io"
 	"testing"
 )
 
@@ -70,3 +72,67 @@ func checkVarIntWrite(t *testing.T, buf *ByteBuffer, value int32) {
 	require.Equal(t, buf.ReaderIndex(), buf.WriterIndex())
 	require.Equal(t, value, varInt)
 }
+
+func TestVarUint64(t *testing.T) {
+	buf := NewByteBuffer(nil)
+	for _, c := range []struct {
+		value        uint64
+		bytesWritten int8
+	}{
+		{0, 1}, {1<<7 - 1, 1}, {1 << 7, 2}, {1<<14 - 1, 2}, {1 << 14, 3}, {1 << 21, 4}, {1 << 28, 5},
+		{1 << 35, 6}, {1 << 42, 7}, {1 << 49, 8}, {1<<56 - 1, 8}, {1 << 56, 9}, {MaxUint64, 9},
+	} {
+		require.Equal(t, c.bytesWritten, buf.WriteVarUint64(c.value))
+		require.Equal(t, c.value, buf.ReadVarUint64())
+		require.Equal(t, buf.ReaderIndex(), buf.WriterIndex())
+	}
+}
+
+func TestSignedVarInt(t *testing.T) {
+	buf := NewByteBuffer(nil)
+	for _, value := range []int32{0, 1, -1, 63, -64, MaxInt32, MinInt32} {
+		bytesWritten := buf.WriteSignedVarInt32(value)
+		if value >= -64 && value <= 63 {
+			require.Equal(t, int8(1), bytesWritten)
+		}
+		require.Equal(t, value, buf.ReadSignedVarInt32())
+	}
+	for _, value := range []int64{0, 1, -1, 63, -64, MaxInt32, MinInt32, MaxInt64, MinInt64} {
+		bytesWritten := buf.WriteSignedVarInt64(value)
+		if value >= -64 && value <= 63 {
+			require.Equal(t, int8(1), bytesWritten)
+		}
+		require.Equal(t, value, buf.ReadSignedVarInt64())
+	}
+	require.Equal(t, buf.ReaderIndex(), buf.WriterIndex())
+}
+
+func TestBufferUnderflow(t *testing.T) {
+	buf := NewByteBuffer([]byte{1, 0x80, 0x80})
+	require.Equal(t, int8(1), buf.ReadInt8())
+	for _, read := range []func(){
+		func() { buf.ReadInt64() },
+		func() { buf.ReadInt32() },
+		func() { buf.ReadFloat64() },
+		func() { buf.ReadBinary(3) },
+		func() { buf.ReadBinary(-1) },
+		func() { buf.ReadVarInt32() },
+	} {
+		readerIndex := buf.ReaderIndex()
+		func() {
+			defer func() {
+				err, ok := recover().(error)
+				require.True(t, ok)
+				require.True(t, errors.Is(err, ErrBufferUnderflow))
+				require.Equal(t, readerIndex, err.(*BufferUnderflowError).Offset)
+			}()
+			read()
+		}()
+		buf.SetReaderIndex(readerIndex)
+	}
+	require.Equal(t, []byte{0x80, 0x80}, buf.ReadBinary(2))
+	_, err := buf.ReadByte()
+	require.Equal(t, io.EOF, err)
+	_, err = buf.Read(make([]byte, 1))
+	require.Equal(t, io.EOF, err)
+}
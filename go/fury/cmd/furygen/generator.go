@@ -0,0 +1,538 @@
+// Licensed to the Apache Software Foundation (ASF) under one
+// or more contributor license agreements.  See the NOTICE file
+// distributed with this work for additional information
+// regarding copyright ownership.  The ASF licenses this file
+// to you under the Apache License, Version 2.0 (the
+// "License"); you may not use this file except in compliance
+// with the License.  You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"github.com/apache/fury/go/fury"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"os"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const (
+	generatedHeader = "// Code generated by furygen. DO NOT EDIT."
+	annotation      = "//fury:generate"
+)
+
+// packageInfo is the parsed package to generate serializers for.
+type packageInfo struct {
+	name string
+	// struct types of the package in declaration order.
+	structs []*ast.TypeSpec
+	// annotated struct types which are generated by default.
+	annotated map[string]bool
+	// all types declared in the package, which shadow the predeclared types.
+	declared map[string]bool
+	// types which implement fury.Marshaller by themselves or by their pointers.
+	marshallers map[string]bool
+}
+
+func parsePackage(dir string) (*packageInfo, error) {
+	fset := token.NewFileSet()
+	filter := func(info os.FileInfo) bool {
+		return !strings.HasSuffix(info.Name(), "_test.go")
+	}
+	pkgs, err := parser.ParseDir(fset, dir, filter, parser.ParseComments)
+	if err != nil {
+		return nil, err
+	}
+	if len(pkgs) != 1 {
+		return nil, fmt.Errorf("expect one package in %s, but got %d", dir, len(pkgs))
+	}
+	pkg := &packageInfo{annotated: map[string]bool{}, declared: map[string]bool{}, marshallers: map[string]bool{}}
+	for name, astPkg := range pkgs {
+		pkg.name = name
+		fileNames := make([]string, 0, len(astPkg.Files))
+		for fileName := range astPkg.Files {
+			fileNames = append(fileNames, fileName)
+		}
+		sort.Strings(fileNames)
+		for _, fileName := range fileNames {
+			pkg.addFile(astPkg.Files[fileName])
+		}
+	}
+	return pkg, nil
+}
+
+func (p *packageInfo) addFile(file *ast.File) {
+	if len(file.Comments) > 0 && strings.HasPrefix(file.Comments[0].Text(), "Code generated by furygen.") {
+		return
+	}
+	for _, decl := range file.Decls {
+		if funcDecl, ok := decl.(*ast.FuncDecl); ok {
+			if funcDecl.Recv != nil && funcDecl.Name.Name == "MarshalFury" {
+				p.marshallers[embeddedName(funcDecl.Recv.List[0].Type)] = true
+			}
+			continue
+		}
+		genDecl, ok := decl.(*ast.GenDecl)
+		if !ok || genDecl.Tok != token.TYPE {
+			continue
+		}
+		for _, spec := range genDecl.Specs {
+			typeSpec := spec.(*ast.TypeSpec)
+			p.declared[typeSpec.Name.Name] = true
+			if _, ok := typeSpec.Type.(*ast.StructType); !ok {
+				continue
+			}
+			p.structs = append(p.structs, typeSpec)
+			doc := typeSpec.Doc
+			if doc == nil && len(genDecl.Specs) == 1 {
+				doc = genDecl.Doc
+			}
+			if hasAnnotation(doc) {
+				p.annotated[typeSpec.Name.Name] = true
+			}
+		}
+	}
+}
+
+func hasAnnotation(doc *ast.CommentGroup) bool {
+	if doc == nil {
+		return false
+	}
+	for _, comment := range doc.List {
+		if strings.TrimSpace(comment.Text) == annotation {
+			return true
+		}
+	}
+	return false
+}
+
+// basicType describes how a field of a predeclared type is written directly.
+type basicType struct {
+	typeId string
+	// format of the statement to write the field value.
+	write string
+	// format of the expression to read the field value.
+	read string
+	// varint encoding of int32/int64, which is used if the field or config chooses INT_ENCODING_VARINT.
+	varint *basicType
+}
+
+var (
+	varInt32Type = &basicType{"FURY_VAR_INT32", "buf.WriteSignedVarInt32(%s)", "buf.ReadSignedVarInt32()", nil}
+	varInt64Type = &basicType{"FURY_VAR_INT64", "buf.WriteSignedVarInt64(%s)", "buf.ReadSignedVarInt64()", nil}
+)
+
+var basicTypes = map[string]*basicType{
+	"bool":    {"BOOL", "buf.WriteBool(%s)", "buf.ReadBool()", nil},
+	"int8":    {"INT8", "buf.WriteInt8(%s)", "buf.ReadInt8()", nil},
+	"byte":    {"UINT8", "buf.WriteByte_(%s)", "buf.ReadByte_()", nil},
+	"uint8":   {"UINT8", "buf.WriteByte_(%s)", "buf.ReadByte_()", nil},
+	"int16":   {"INT16", "buf.WriteInt16(%s)", "buf.ReadInt16()", nil},
+	"uint16":  {"UINT16", "buf.WriteInt16(int16(%s))", "uint16(buf.ReadInt16())", nil},
+	"int32":   {"INT32", "buf.WriteInt32(%s)", "buf.ReadInt32()", varInt32Type},
+	"uint32":  {"UINT32", "buf.WriteInt32(int32(%s))", "buf.ReadUint32()", nil},
+	"int64":   {"INT64", "buf.WriteInt64(%s)", "buf.ReadInt64()", varInt64Type},
+	"uint64":  {"UINT64", "buf.WriteInt64(int64(%s))", "buf.ReadUint64()", nil},
+	"float32": {"FLOAT", "buf.WriteFloat32(%s)", "buf.ReadFloat32()", nil},
+	"float64": {"DOUBLE", "buf.WriteFloat64(%s)", "buf.ReadFloat64()", nil},
+}
+
+// fieldEncoding is the int encoding of a field, which is set by the `encoding` option of fury tag.
+type fieldEncoding int
+
+const (
+	encodingDefault fieldEncoding = iota
+	encodingFixed
+	encodingVarint
+)
+
+type field struct {
+	name string
+	// name used to sort fields, which is the same as the struct serializer of fury.
+	sortName string
+	// basic type of the field, nil for strings and fields which aren't written directly.
+	basic    *basicType
+	isString bool
+	encoding fieldEncoding
+}
+
+// encodingByConfig returns whether the field encoding is decided by the int encoding of config.
+func (f *field) encodingByConfig() bool {
+	return f.basic != nil && f.basic.varint != nil && f.encoding == encodingDefault
+}
+
+// basicType returns the type used to write the field whose encoding isn't decided by config.
+func (f *field) basicType() *basicType {
+	if f.basic.varint != nil && f.encoding == encodingVarint {
+		return f.basic.varint
+	}
+	return f.basic
+}
+
+// generate returns the formatted code of the serializers of `typeNames`, or the annotated structs if
+// `typeNames` is empty.
+func generate(pkg *packageInfo, typeNames []string) ([]byte, error) {
+	var specs []*ast.TypeSpec
+	if len(typeNames) == 0 {
+		for _, spec := range pkg.structs {
+			if pkg.annotated[spec.Name.Name] {
+				specs = append(specs, spec)
+			}
+		}
+		if len(specs) == 0 {
+			return nil, fmt.Errorf("no struct is annotated by %s in package %s", annotation, pkg.name)
+		}
+	} else {
+		for _, name := range typeNames {
+			spec := pkg.lookupStruct(name)
+			if spec == nil {
+				return nil, fmt.Errorf("struct %s is not found in package %s", name, pkg.name)
+			}
+			specs = append(specs, spec)
+		}
+	}
+	g := &generator{pkg: pkg}
+	for _, spec := range specs {
+		if err := g.generateStruct(spec); err != nil {
+			return nil, err
+		}
+	}
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "%s\n\npackage %s\n\nimport (\n", generatedHeader, pkg.name)
+	if g.usesReflect {
+		buf.WriteString("\"reflect\"\n\n")
+	}
+	buf.WriteString("\"github.com/apache/fury/go/fury\"\n)\n\nfunc init() {\n")
+	for _, spec := range specs {
+		fmt.Fprintf(&buf, "fury.RegisterGeneratedSerializer(%s{}, %s{})\n", spec.Name.Name, serializerName(spec))
+	}
+	buf.WriteString("}\n")
+	buf.Write(g.buf.Bytes())
+	src, err := format.Source(buf.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("format generated code: %s", err)
+	}
+	return src, nil
+}
+
+func (p *packageInfo) lookupStruct(name string) *ast.TypeSpec {
+	for _, spec := range p.structs {
+		if spec.Name.Name == name {
+			return spec
+		}
+	}
+	return nil
+}
+
+func serializerName(spec *ast.TypeSpec) string {
+	name := spec.Name.Name
+	return strings.ToLower(name[:1]) + name[1:] + "FurySerializer"
+}
+
+type generator struct {
+	pkg         *packageInfo
+	buf         bytes.Buffer
+	usesReflect bool
+}
+
+func (g *generator) printf(format string, args ...interface{}) {
+	fmt.Fprintf(&g.buf, format, args...)
+}
+
+func (g *generator) generateStruct(spec *ast.TypeSpec) error {
+	fields, err := g.parseFields(spec)
+	if err != nil {
+		return err
+	}
+	typeName, name := spec.Name.Name, serializerName(spec)
+	g.printf("\n// %s is the generated fury serializer of %s.\ntype %s struct{}\n\n", name, typeName, name)
+	g.printf("func (%s) Fields() []string {\nreturn []string{", name)
+	for i, f := range fields {
+		if i > 0 {
+			g.printf(", ")
+		}
+		g.printf("%q", f.name)
+	}
+	g.printf("}\n}\n\n")
+
+	g.printf("func (%s) WriteFields(f *fury.Fury, buf *fury.ByteBuffer, fields *fury.StructFields, "+
+		"ptr interface{}) error {\n", name)
+	g.printHeader(typeName, fields)
+	for i, f := range fields {
+		value := "v." + f.name
+		switch {
+		case f.isString:
+			g.printf("if err := f.WriteStringField(buf, %s); err != nil {\nreturn err\n}\n", value)
+		case f.basic != nil:
+			g.printf("buf.WriteInt8(fury.NotNullValueFlag)\n")
+			if f.encodingByConfig() {
+				g.printf("if varint {\n")
+				g.printBasicWrite(f.basic.varint, value)
+				g.printf("} else {\n")
+				g.printBasicWrite(f.basic, value)
+				g.printf("}\n")
+			} else {
+				g.printBasicWrite(f.basicType(), value)
+			}
+		default:
+			g.printf("if err := fields.WriteField(f, buf, %d, reflect.ValueOf(&%s).Elem()); err != nil {\n"+
+				"return err\n}\n", i, value)
+		}
+	}
+	g.printf("return nil\n}\n\n")
+
+	g.printf("func (%s) ReadFields(f *fury.Fury, buf *fury.ByteBuffer, fields *fury.StructFields, "+
+		"ptr interface{}) error {\n", name)
+	g.printHeader(typeName, fields)
+	for i, f := range fields {
+		value := "v." + f.name
+		switch {
+		case f.isString:
+			g.printf("if value, err := f.ReadStringField(buf); err != nil {\nreturn err\n} else {\n"+
+				"%s = value\n}\n", value)
+		case f.basic != nil:
+			if f.encodingByConfig() {
+				g.printf("if varint {\n")
+				g.printBasicRead(f.basic.varint, value)
+				g.printf("} else {\n")
+				g.printBasicRead(f.basic, value)
+				g.printf("}\n")
+			} else {
+				g.printBasicRead(f.basicType(), value)
+			}
+		default:
+			g.printf("if err := fields.ReadField(f, buf, %d, reflect.ValueOf(&%s).Elem()); err != nil {\n"+
+				"return err\n}\n", i, value)
+		}
+	}
+	g.printf("return nil\n}\n")
+	return nil
+}
+
+// printBasicWrite prints the statements to write the type id and the value of a field of basic type `t`.
+func (g *generator) printBasicWrite(t *basicType, value string) {
+	g.printf("buf.WriteInt16(fury.%s)\n"+t.write+"\n", t.typeId, value)
+}
+
+// printBasicRead prints the statements to read the type id and the value of a field of basic type `t`.
+func (g *generator) printBasicRead(t *basicType, value string) {
+	g.printf("if err := f.ReadFieldHeader(buf, fury.%s); err != nil {\nreturn err\n}\n%s = %s\n", t.typeId, value,
+		t.read)
+}
+
+// printHeader prints the variables used by the fields of a Write/Read method.
+func (g *generator) printHeader(typeName string, fields []*field) {
+	if len(fields) == 0 {
+		return
+	}
+	g.printf("v := ptr.(*%s)\n", typeName)
+	for _, f := range fields {
+		if f.encodingByConfig() {
+			g.printf("varint := f.Config().IntEncoding() == fury.INT_ENCODING_VARINT\n")
+			break
+		}
+	}
+}
+
+// parseFields returns the serialized fields of the struct in the order of the struct serializer of fury. Fields of
+// embedded structs are flattened by the promotion rules of golang, the same as the struct serializer.
+func (g *generator) parseFields(spec *ast.TypeSpec) ([]*field, error) {
+	promoted, err := g.collectFields(spec, spec.Type.(*ast.StructType), 0, true, map[string]bool{}, nil)
+	if err != nil {
+		return nil, err
+	}
+	// a field is hidden by fields of the same name in a shallower depth, and fields of the same name in the same
+	// depth are ambiguous, see `reflect.VisibleFields`.
+	depths, counts := map[string]int{}, map[string]int{}
+	for _, f := range promoted {
+		if depth, ok := depths[f.name]; !ok || f.depth < depth {
+			depths[f.name], counts[f.name] = f.depth, 1
+		} else if f.depth == depth {
+			counts[f.name]++
+		}
+	}
+	var fields []*field
+	for _, f := range promoted {
+		if f.depth != depths[f.name] || counts[f.name] > 1 || !f.serialized {
+			continue
+		}
+		tag, err := parseTag(f.astField)
+		if err != nil {
+			return nil, fmt.Errorf("struct %s: %s", spec.Name.Name, err)
+		}
+		if tag.ignored || !ast.IsExported(f.name) {
+			continue
+		}
+		var basic *basicType
+		isString := false
+		if ident, ok := f.astField.Type.(*ast.Ident); ok && !g.pkg.declared[ident.Name] {
+			basic, isString = basicTypes[ident.Name], ident.Name == "string"
+			if tag.encoding != encodingDefault && (isString || basic != nil && basic.varint == nil) {
+				return nil, fmt.Errorf("struct %s: encoding option can't be used by field %s of type %s",
+					spec.Name.Name, f.name, ident.Name)
+			}
+			if basic != nil && tag.nullableOption != "" {
+				return nil, fmt.Errorf("struct %s: option %s can't be used by field %s of type %s",
+					spec.Name.Name, tag.nullableOption, f.name, ident.Name)
+			}
+			if isString && (tag.notNullable || tag.noRef) {
+				// strings which aren't nullable or referencable are written by the struct serializer.
+				isString = false
+			}
+		}
+		if basic == nil && !isString {
+			g.usesReflect = true
+		}
+		sortName := tag.name
+		if sortName == "" {
+			sortName = fury.SnakeCase(f.name)
+		}
+		fields = append(fields, &field{
+			name: f.name, sortName: sortName, basic: basic, isString: isString, encoding: tag.encoding})
+	}
+	sort.Slice(fields, func(i, j int) bool {
+		return fields[i].sortName < fields[j].sortName
+	})
+	for i := 1; i < len(fields); i++ {
+		if fields[i].sortName == fields[i-1].sortName {
+			return nil, fmt.Errorf("struct %s: fields %s and %s have the same name %s", spec.Name.Name,
+				fields[i-1].name, fields[i].name, fields[i].sortName)
+		}
+	}
+	return fields, nil
+}
+
+// promotedField is a field of a struct or of its embedded structs.
+type promotedField struct {
+	astField *ast.Field
+	name     string
+	depth    int
+	// serialized is whether the field is a field of the struct, which means it's only embedded by flattened
+	// structs and isn't a flattened struct itself.
+	serialized bool
+}
+
+// collectFields appends the fields of `structType` in `depth` and the fields of its embedded structs declared in
+// the package to `fields`. `flattened` is whether `structType` is flattened into the struct to generate, and
+// `visiting` is the embedded structs in the path, which are skipped to avoid infinite recursion.
+func (g *generator) collectFields(spec *ast.TypeSpec, structType *ast.StructType, depth int, flattened bool,
+	visiting map[string]bool, fields []*promotedField) ([]*promotedField, error) {
+	for _, astField := range structType.Fields.List {
+		if len(astField.Names) > 0 {
+			for _, name := range astField.Names {
+				fields = append(fields, &promotedField{astField, name.Name, depth, flattened})
+			}
+			continue
+		}
+		// embedded field is named by its type name.
+		name := embeddedName(astField.Type)
+		tag, err := parseTag(astField)
+		if err != nil {
+			return nil, fmt.Errorf("struct %s: %s", spec.Name.Name, err)
+		}
+		typeExpr := astField.Type
+		star, isPtr := typeExpr.(*ast.StarExpr)
+		if isPtr {
+			typeExpr = star.X
+		}
+		_, isSelector := typeExpr.(*ast.SelectorExpr)
+		if isSelector && flattened && !isPtr && !tag.ignored && tag.name == "" {
+			return nil, fmt.Errorf("struct %s: embedded field %s of another package may be flattened by fury, "+
+				"name it by fury tag or skip it", spec.Name.Name, name)
+		}
+		embedded := g.pkg.lookupStruct(name)
+		if isSelector || embedded == nil || visiting[name] {
+			fields = append(fields, &promotedField{astField, name, depth, flattened})
+			continue
+		}
+		isFlattened := !isPtr && !tag.ignored && tag.name == "" && !g.pkg.marshallers[name]
+		fields = append(fields, &promotedField{astField, name, depth, flattened && !isFlattened})
+		visiting[name] = true
+		fields, err = g.collectFields(spec, embedded.Type.(*ast.StructType), depth+1, flattened && isFlattened,
+			visiting, fields)
+		if err != nil {
+			return nil, err
+		}
+		delete(visiting, name)
+	}
+	return fields, nil
+}
+
+func embeddedName(expr ast.Expr) string {
+	switch t := expr.(type) {
+	case *ast.StarExpr:
+		return embeddedName(t.X)
+	case *ast.SelectorExpr:
+		return t.Sel.Name
+	case *ast.Ident:
+		return t.Name
+	}
+	return ""
+}
+
+// fieldTag is the fury tag of a field, see parseTag.
+type fieldTag struct {
+	name        string
+	ignored     bool
+	notNullable bool
+	noRef       bool
+	// nullableOption is the `nullable=true` or `ref=true` option, which can only be used by nullable types.
+	nullableOption string
+	encoding       fieldEncoding
+}
+
+// parseTag parses the fury tag of the field in the same way as fury, which is `fury:"[name][,option]..."`
+// or `fury:"-"`.
+func parseTag(astField *ast.Field) (fieldTag, error) {
+	var tag fieldTag
+	if astField.Tag == nil {
+		return tag, nil
+	}
+	structTag, err := strconv.Unquote(astField.Tag.Value)
+	if err != nil {
+		return tag, err
+	}
+	value, ok := reflect.StructTag(structTag).Lookup("fury")
+	if !ok {
+		return tag, nil
+	}
+	if value == "-" {
+		tag.ignored = true
+		return tag, nil
+	}
+	parts := strings.Split(value, ",")
+	tag.name = parts[0]
+	for _, option := range parts[1:] {
+		switch option {
+		case "nullable=true", "ref=true":
+			tag.nullableOption = option
+		case "nullable=false":
+			tag.notNullable = true
+		case "ref=false":
+			tag.noRef = true
+		case "encoding=fixed":
+			tag.encoding = encodingFixed
+		case "encoding=varint":
+			tag.encoding = encodingVarint
+		default:
+			return tag, fmt.Errorf("unknown option %s in fury tag %q", option, value)
+		}
+	}
+	return tag, nil
+}
@@ -0,0 +1,67 @@
+// Licensed to the Apache Software Foundation (ASF) under one
+// or more contributor license agreements.  See the NOTICE file
+// distributed with this work for additional information
+// regarding copyright ownership.  The ASF licenses this file
+// to you under the Apache License, Version 2.0 (the
+// "License"); you may not use this file except in compliance
+// with the License.  You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package main
+
+import (
+	"github.com/stretchr/testify/require"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestGenerate(t *testing.T) {
+	// the generated code of the example package must be up to date.
+	pkg, err := parsePackage("internal/example")
+	require.Nil(t, err)
+	src, err := generate(pkg, nil)
+	require.Nil(t, err)
+	expected, err := ioutil.ReadFile("internal/example/example_fury.go")
+	require.Nil(t, err)
+	require.Equal(t, string(expected), string(src))
+
+	// generate by type names.
+	src, err = generate(pkg, []string{"Item"})
+	require.Nil(t, err)
+	require.Contains(t, string(src), "itemFurySerializer")
+	require.NotContains(t, string(src), "orderFurySerializer")
+	require.NotContains(t, string(src), "\"reflect\"")
+	_, err = generate(pkg, []string{"Unknown"})
+	require.Error(t, err)
+}
+
+func TestGenerateErrors(t *testing.T) {
+	dir, err := ioutil.TempDir("", "furygen")
+	require.Nil(t, err)
+	defer os.RemoveAll(dir)
+	for _, src := range []string{
+		"package p\n\ntype A struct{ X int32 }\n",
+		"package p\n\n//fury:generate\ntype A struct {\n\tX int32 `fury:\"y\"`\n\tY int32\n}\n",
+		"package p\n\n//fury:generate\ntype A struct {\n\tX int32 `fury:\",ref=true\"`\n}\n",
+		"package p\n\n//fury:generate\ntype A struct {\n\tX int32 `fury:\",nullable\"`\n}\n",
+		"package p\n\n//fury:generate\ntype A struct {\n\tX string `fury:\",encoding=varint\"`\n}\n",
+		"package p\n\n//fury:generate\ntype A struct {\n\tX uint32 `fury:\",encoding=varint\"`\n}\n",
+		"package p\n\nimport \"time\"\n\n//fury:generate\ntype A struct {\n\ttime.Time\n}\n",
+	} {
+		require.Nil(t, ioutil.WriteFile(filepath.Join(dir, "p.go"), []byte(src), 0644))
+		pkg, err := parsePackage(dir)
+		require.Nil(t, err)
+		_, err = generate(pkg, nil)
+		require.Error(t, err, src)
+	}
+}
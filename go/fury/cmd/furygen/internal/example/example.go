@@ -0,0 +1,65 @@
+// Licensed to the Apache Software Foundation (ASF) under one
+// or more contributor license agreements.  See the NOTICE file
+// distributed with this work for additional information
+// regarding copyright ownership.  The ASF licenses this file
+// to you under the Apache License, Version 2.0 (the
+// "License"); you may not use this file except in compliance
+// with the License.  You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+// Package example shows the serializers generated by furygen, which are tested to write the same data as
+// serializers based on reflection.
+package example
+
+//go:generate go run github.com/apache/fury/go/fury/cmd/furygen
+
+// Header is embedded in Order.
+type Header struct {
+	Version uint16
+	Trace   string
+}
+
+// Item is an item of Order.
+//
+//fury:generate
+type Item struct {
+	Name     string
+	Price    float64
+	Quantity int32
+}
+
+// Order has fields of all kinds, fields of basic types and strings are written directly by the generated code.
+//
+//fury:generate
+type Order struct {
+	Header
+	Id       int64  `fury:",encoding=varint"`
+	Total    int32  `fury:",encoding=fixed"`
+	Customer string `fury:"buyer"`
+	Address  string `fury:",nullable=false"`
+	Gift     *Item  `fury:",ref=false"`
+	Cache    []byte `fury:"-"`
+	Items    []Item
+	Tags     map[string]string
+	Note     *string
+	Paid     bool
+	Discount float32
+	Ratio    float64
+	Flags    byte
+	Level    int8
+	Shard    int16
+	Count    int32
+	Created  int64
+	Size     uint32
+	Mask     uint64
+	Score    int
+	secret   string
+}
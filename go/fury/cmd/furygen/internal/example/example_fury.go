@@ -0,0 +1,253 @@
+// Code generated by furygen. DO NOT EDIT.
+
+package example
+
+import (
+	"reflect"
+
+	"github.com/apache/fury/go/fury"
+)
+
+func init() {
+	fury.RegisterGeneratedSerializer(Item{}, itemFurySerializer{})
+	fury.RegisterGeneratedSerializer(Order{}, orderFurySerializer{})
+}
+
+// itemFurySerializer is the generated fury serializer of Item.
+type itemFurySerializer struct{}
+
+func (itemFurySerializer) Fields() []string {
+	return []string{"Name", "Price", "Quantity"}
+}
+
+func (itemFurySerializer) WriteFields(f *fury.Fury, buf *fury.ByteBuffer, fields *fury.StructFields, ptr interface{}) error {
+	v := ptr.(*Item)
+	varint := f.Config().IntEncoding() == fury.INT_ENCODING_VARINT
+	if err := f.WriteStringField(buf, v.Name); err != nil {
+		return err
+	}
+	buf.WriteInt8(fury.NotNullValueFlag)
+	buf.WriteInt16(fury.DOUBLE)
+	buf.WriteFloat64(v.Price)
+	buf.WriteInt8(fury.NotNullValueFlag)
+	if varint {
+		buf.WriteInt16(fury.FURY_VAR_INT32)
+		buf.WriteSignedVarInt32(v.Quantity)
+	} else {
+		buf.WriteInt16(fury.INT32)
+		buf.WriteInt32(v.Quantity)
+	}
+	return nil
+}
+
+func (itemFurySerializer) ReadFields(f *fury.Fury, buf *fury.ByteBuffer, fields *fury.StructFields, ptr interface{}) error {
+	v := ptr.(*Item)
+	varint := f.Config().IntEncoding() == fury.INT_ENCODING_VARINT
+	if value, err := f.ReadStringField(buf); err != nil {
+		return err
+	} else {
+		v.Name = value
+	}
+	if err := f.ReadFieldHeader(buf, fury.DOUBLE); err != nil {
+		return err
+	}
+	v.Price = buf.ReadFloat64()
+	if varint {
+		if err := f.ReadFieldHeader(buf, fury.FURY_VAR_INT32); err != nil {
+			return err
+		}
+		v.Quantity = buf.ReadSignedVarInt32()
+	} else {
+		if err := f.ReadFieldHeader(buf, fury.INT32); err != nil {
+			return err
+		}
+		v.Quantity = buf.ReadInt32()
+	}
+	return nil
+}
+
+// orderFurySerializer is the generated fury serializer of Order.
+type orderFurySerializer struct{}
+
+func (orderFurySerializer) Fields() []string {
+	return []string{"Address", "Customer", "Count", "Created", "Discount", "Flags", "Gift", "Id", "Items", "Level", "Mask", "Note", "Paid", "Ratio", "Score", "Shard", "Size", "Tags", "Total", "Trace", "Version"}
+}
+
+func (orderFurySerializer) WriteFields(f *fury.Fury, buf *fury.ByteBuffer, fields *fury.StructFields, ptr interface{}) error {
+	v := ptr.(*Order)
+	varint := f.Config().IntEncoding() == fury.INT_ENCODING_VARINT
+	if err := fields.WriteField(f, buf, 0, reflect.ValueOf(&v.Address).Elem()); err != nil {
+		return err
+	}
+	if err := f.WriteStringField(buf, v.Customer); err != nil {
+		return err
+	}
+	buf.WriteInt8(fury.NotNullValueFlag)
+	if varint {
+		buf.WriteInt16(fury.FURY_VAR_INT32)
+		buf.WriteSignedVarInt32(v.Count)
+	} else {
+		buf.WriteInt16(fury.INT32)
+		buf.WriteInt32(v.Count)
+	}
+	buf.WriteInt8(fury.NotNullValueFlag)
+	if varint {
+		buf.WriteInt16(fury.FURY_VAR_INT64)
+		buf.WriteSignedVarInt64(v.Created)
+	} else {
+		buf.WriteInt16(fury.INT64)
+		buf.WriteInt64(v.Created)
+	}
+	buf.WriteInt8(fury.NotNullValueFlag)
+	buf.WriteInt16(fury.FLOAT)
+	buf.WriteFloat32(v.Discount)
+	buf.WriteInt8(fury.NotNullValueFlag)
+	buf.WriteInt16(fury.UINT8)
+	buf.WriteByte_(v.Flags)
+	if err := fields.WriteField(f, buf, 6, reflect.ValueOf(&v.Gift).Elem()); err != nil {
+		return err
+	}
+	buf.WriteInt8(fury.NotNullValueFlag)
+	buf.WriteInt16(fury.FURY_VAR_INT64)
+	buf.WriteSignedVarInt64(v.Id)
+	if err := fields.WriteField(f, buf, 8, reflect.ValueOf(&v.Items).Elem()); err != nil {
+		return err
+	}
+	buf.WriteInt8(fury.NotNullValueFlag)
+	buf.WriteInt16(fury.INT8)
+	buf.WriteInt8(v.Level)
+	buf.WriteInt8(fury.NotNullValueFlag)
+	buf.WriteInt16(fury.UINT64)
+	buf.WriteInt64(int64(v.Mask))
+	if err := fields.WriteField(f, buf, 11, reflect.ValueOf(&v.Note).Elem()); err != nil {
+		return err
+	}
+	buf.WriteInt8(fury.NotNullValueFlag)
+	buf.WriteInt16(fury.BOOL)
+	buf.WriteBool(v.Paid)
+	buf.WriteInt8(fury.NotNullValueFlag)
+	buf.WriteInt16(fury.DOUBLE)
+	buf.WriteFloat64(v.Ratio)
+	if err := fields.WriteField(f, buf, 14, reflect.ValueOf(&v.Score).Elem()); err != nil {
+		return err
+	}
+	buf.WriteInt8(fury.NotNullValueFlag)
+	buf.WriteInt16(fury.INT16)
+	buf.WriteInt16(v.Shard)
+	buf.WriteInt8(fury.NotNullValueFlag)
+	buf.WriteInt16(fury.UINT32)
+	buf.WriteInt32(int32(v.Size))
+	if err := fields.WriteField(f, buf, 17, reflect.ValueOf(&v.Tags).Elem()); err != nil {
+		return err
+	}
+	buf.WriteInt8(fury.NotNullValueFlag)
+	buf.WriteInt16(fury.INT32)
+	buf.WriteInt32(v.Total)
+	if err := f.WriteStringField(buf, v.Trace); err != nil {
+		return err
+	}
+	buf.WriteInt8(fury.NotNullValueFlag)
+	buf.WriteInt16(fury.UINT16)
+	buf.WriteInt16(int16(v.Version))
+	return nil
+}
+
+func (orderFurySerializer) ReadFields(f *fury.Fury, buf *fury.ByteBuffer, fields *fury.StructFields, ptr interface{}) error {
+	v := ptr.(*Order)
+	varint := f.Config().IntEncoding() == fury.INT_ENCODING_VARINT
+	if err := fields.ReadField(f, buf, 0, reflect.ValueOf(&v.Address).Elem()); err != nil {
+		return err
+	}
+	if value, err := f.ReadStringField(buf); err != nil {
+		return err
+	} else {
+		v.Customer = value
+	}
+	if varint {
+		if err := f.ReadFieldHeader(buf, fury.FURY_VAR_INT32); err != nil {
+			return err
+		}
+		v.Count = buf.ReadSignedVarInt32()
+	} else {
+		if err := f.ReadFieldHeader(buf, fury.INT32); err != nil {
+			return err
+		}
+		v.Count = buf.ReadInt32()
+	}
+	if varint {
+		if err := f.ReadFieldHeader(buf, fury.FURY_VAR_INT64); err != nil {
+			return err
+		}
+		v.Created = buf.ReadSignedVarInt64()
+	} else {
+		if err := f.ReadFieldHeader(buf, fury.INT64); err != nil {
+			return err
+		}
+		v.Created = buf.ReadInt64()
+	}
+	if err := f.ReadFieldHeader(buf, fury.FLOAT); err != nil {
+		return err
+	}
+	v.Discount = buf.ReadFloat32()
+	if err := f.ReadFieldHeader(buf, fury.UINT8); err != nil {
+		return err
+	}
+	v.Flags = buf.ReadByte_()
+	if err := fields.ReadField(f, buf, 6, reflect.ValueOf(&v.Gift).Elem()); err != nil {
+		return err
+	}
+	if err := f.ReadFieldHeader(buf, fury.FURY_VAR_INT64); err != nil {
+		return err
+	}
+	v.Id = buf.ReadSignedVarInt64()
+	if err := fields.ReadField(f, buf, 8, reflect.ValueOf(&v.Items).Elem()); err != nil {
+		return err
+	}
+	if err := f.ReadFieldHeader(buf, fury.INT8); err != nil {
+		return err
+	}
+	v.Level = buf.ReadInt8()
+	if err := f.ReadFieldHeader(buf, fury.UINT64); err != nil {
+		return err
+	}
+	v.Mask = buf.ReadUint64()
+	if err := fields.ReadField(f, buf, 11, reflect.ValueOf(&v.Note).Elem()); err != nil {
+		return err
+	}
+	if err := f.ReadFieldHeader(buf, fury.BOOL); err != nil {
+		return err
+	}
+	v.Paid = buf.ReadBool()
+	if err := f.ReadFieldHeader(buf, fury.DOUBLE); err != nil {
+		return err
+	}
+	v.Ratio = buf.ReadFloat64()
+	if err := fields.ReadField(f, buf, 14, reflect.ValueOf(&v.Score).Elem()); err != nil {
+		return err
+	}
+	if err := f.ReadFieldHeader(buf, fury.INT16); err != nil {
+		return err
+	}
+	v.Shard = buf.ReadInt16()
+	if err := f.ReadFieldHeader(buf, fury.UINT32); err != nil {
+		return err
+	}
+	v.Size = buf.ReadUint32()
+	if err := fields.ReadField(f, buf, 17, reflect.ValueOf(&v.Tags).Elem()); err != nil {
+		return err
+	}
+	if err := f.ReadFieldHeader(buf, fury.INT32); err != nil {
+		return err
+	}
+	v.Total = buf.ReadInt32()
+	if value, err := f.ReadStringField(buf); err != nil {
+		return err
+	} else {
+		v.Trace = value
+	}
+	if err := f.ReadFieldHeader(buf, fury.UINT16); err != nil {
+		return err
+	}
+	v.Version = uint16(buf.ReadInt16())
+	return nil
+}
@@ -0,0 +1,98 @@
+// Licensed to the Apache Software Foundation (ASF) under one
+// or more contributor license agreements.  See the NOTICE file
+// distributed with this work for additional information
+// regarding copyright ownership.  The ASF licenses this file
+// to you under the Apache License, Version 2.0 (the
+// "License"); you may not use this file except in compliance
+// with the License.  You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package example
+
+import (
+	"github.com/apache/fury/go/fury"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+// types with the same fields which are serialized by reflection.
+type reflectItem Item
+type reflectOrder Order
+
+func TestGeneratedSerializer(t *testing.T) {
+	note := "note"
+	item := Item{Name: "apple", Price: 1.5, Quantity: -2}
+	order := Order{
+		Header: Header{Version: 1, Trace: "trace"}, Id: -1, Total: 100, Customer: "customer", Address: "address",
+		Gift:  &Item{Name: "gift"},
+		Items: []Item{item, {Name: "pear"}}, Tags: map[string]string{"k": "v"}, Note: &note, Paid: true,
+		Discount: 0.5, Ratio: 0.25, Flags: 1, Level: -1, Shard: -2, Count: 3, Created: 4, Size: 5, Mask: 6, Score: 7,
+	}
+	for _, opts := range [][]fury.Option{
+		{},
+		{fury.WithRefTracking(true)},
+		{fury.WithLanguage(fury.GO), fury.WithIntEncoding(fury.INT_ENCODING_VARINT)},
+		{fury.WithCompatibleMode(fury.COMPATIBLE)},
+		{fury.WithLanguage(fury.GO)},
+	} {
+		generated := fury.NewFury(opts...)
+		require.Nil(t, generated.RegisterTagType("example.Item", Item{}))
+		require.Nil(t, generated.RegisterTagType("example.Order", Order{}))
+		reflectiveItem := fury.NewFury(opts...)
+		require.Nil(t, reflectiveItem.RegisterTagType("example.Item", reflectItem{}))
+		// Items of reflectOrder are still serialized by the generated serializer of Item.
+		reflectiveOrder := fury.NewFury(opts...)
+		require.Nil(t, reflectiveOrder.RegisterTagType("example.Item", Item{}))
+		require.Nil(t, reflectiveOrder.RegisterTagType("example.Order", reflectOrder{}))
+		reflectedOrder := reflectOrder(order)
+		for _, c := range []struct {
+			reflective                                 *fury.Fury
+			value, reflectValue, target, reflectTarget interface{}
+		}{
+			{reflectiveItem, item, reflectItem(item), &Item{}, &reflectItem{}},
+			{reflectiveOrder, order, reflectedOrder, &Order{}, &reflectOrder{}},
+			{reflectiveOrder, &order, &reflectedOrder, new(*Order), new(*reflectOrder)},
+			{reflectiveOrder, []Order{order, {}}, []reflectOrder{reflectedOrder, {}}, &[]Order{}, &[]reflectOrder{}},
+		} {
+			bytes, err := generated.Marshal(c.value)
+			require.Nil(t, err)
+			reflectBytes, err := c.reflective.Marshal(c.reflectValue)
+			require.Nil(t, err)
+			require.Equal(t, reflectBytes, bytes)
+			require.Nil(t, generated.Unmarshal(reflectBytes, c.target))
+			require.Nil(t, c.reflective.Unmarshal(bytes, c.reflectTarget))
+			require.Equal(t, c.value, indirect(c.target))
+			require.Equal(t, c.reflectValue, indirect(c.reflectTarget))
+		}
+	}
+}
+
+func indirect(ptr interface{}) interface{} {
+	switch v := ptr.(type) {
+	case *Item:
+		return *v
+	case *reflectItem:
+		return *v
+	case *Order:
+		return *v
+	case *reflectOrder:
+		return *v
+	case **Order:
+		return *v
+	case **reflectOrder:
+		return *v
+	case *[]Order:
+		return *v
+	case *[]reflectOrder:
+		return *v
+	}
+	panic("unexpected type")
+}
@@ -0,0 +1,93 @@
+// Licensed to the Apache Software Foundation (ASF) under one
+// or more contributor license agreements.  See the NOTICE file
+// distributed with this work for additional information
+// regarding copyright ownership.  The ASF licenses this file
+// to you under the Apache License, Version 2.0 (the
+// "License"); you may not use this file except in compliance
+// with the License.  You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+// Furygen generates fury serializers which write and read struct fields without reflection. The generated
+// serializers register themselves in `init`, and write the same data as the serializers based on reflection.
+//
+// Structs are selected by the -type flag, or by a `//fury:generate` line in their doc comments:
+//
+//	//go:generate go run github.com/apache/fury/go/fury/cmd/furygen
+//
+//	//fury:generate
+//	type Order struct {
+//		Id    int64
+//		Items []Item
+//	}
+//
+// Usage:
+//
+//	furygen [-type T1,T2] [-output file] [dir]
+//
+// The code is written to `<file>_fury.go` for `go generate` invoked from `<file>.go`, or `<package>_fury.go`
+// otherwise. Fields of basic types and strings are written directly, other fields such as slices, maps and
+// structs are written by the fury serializers of their types. Unexported fields aren't generated, so the
+// generated serializers can't be used with `fury.WithUnexportedFields`.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+func main() {
+	typeNames := flag.String("type", "", "comma-separated list of struct names, default to annotated structs")
+	output := flag.String("output", "", "output file name")
+	flag.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: furygen [-type T1,T2] [-output file] [dir]\n")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	dir := "."
+	if flag.NArg() > 1 {
+		flag.Usage()
+		os.Exit(2)
+	} else if flag.NArg() == 1 {
+		dir = flag.Arg(0)
+	}
+	if err := run(dir, *typeNames, *output); err != nil {
+		fmt.Fprintf(os.Stderr, "furygen: %s\n", err)
+		os.Exit(1)
+	}
+}
+
+func run(dir string, typeNames string, output string) error {
+	pkg, err := parsePackage(dir)
+	if err != nil {
+		return err
+	}
+	var names []string
+	if typeNames != "" {
+		names = strings.Split(typeNames, ",")
+	}
+	src, err := generate(pkg, names)
+	if err != nil {
+		return err
+	}
+	if output == "" {
+		if goFile := os.Getenv("GOFILE"); goFile != "" {
+			output = strings.TrimSuffix(goFile, ".go") + "_fury.go"
+		} else {
+			output = pkg.name + "_fury.go"
+		}
+		output = filepath.Join(dir, output)
+	}
+	return ioutil.WriteFile(output, src, 0644)
+}
@@ -0,0 +1,306 @@
+// Licensed to the Apache Software Foundation (ASF) under one
+// or more contributor license agreements.  See the NOTICE file
+// distributed with this work for additional information
+// regarding copyright ownership.  The ASF licenses this file
+// to you under the Apache License, Version 2.0 (the
+// "License"); you may not use this file except in compliance
+// with the License.  You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package fury
+
+import "fmt"
+
+// Config holds the options of Fury. A Config is immutable once created, so it can be shared by multiple goroutines
+// and used to create multiple Fury instances with the same behaviour.
+type Config struct {
+	referenceTracking bool
+	language          Language
+	compatibleMode    CompatibleMode
+	unexportedFields  bool
+	maxDepth          int
+	maxCollectionSize int
+	maxBinarySize     int
+	maxTotalBytes     int
+	typeChecker       TypeChecker
+	intEncoding       IntEncoding
+	timestampUnit     TimeUnit
+	stringEncoding    StringEncoding
+}
+
+// IntEncoding is the encoding of int32/int64/int values.
+type IntEncoding = uint8
+
+const (
+	// INT_ENCODING_FIXED writes int32 as 4 bytes and int64/int as 8 bytes in little endian order.
+	INT_ENCODING_FIXED IntEncoding = iota
+	// INT_ENCODING_VARINT writes int32 as signed varint32 and int64/int as signed varint64, which takes fewer
+	// bytes for small values. It's golang specific.
+	INT_ENCODING_VARINT
+)
+
+// TimeUnit is the unit of TIMESTAMP values.
+type TimeUnit = uint8
+
+const (
+	// TIME_UNIT_MICRO writes timestamps in microseconds, which is the precision of java and python peers.
+	TIME_UNIT_MICRO TimeUnit = iota
+	// TIME_UNIT_NANO writes timestamps in nanoseconds, which is the precision of `time.Time` in the range of
+	// years 1678 to 2262.
+	TIME_UNIT_NANO
+)
+
+// StringEncoding is the encoding of string values.
+type StringEncoding = uint8
+
+const (
+	// STRING_ENCODING_UTF8 writes strings as UTF-8 bytes, which takes fewer bytes for ASCII text.
+	STRING_ENCODING_UTF8 StringEncoding = iota
+	// STRING_ENCODING_UTF16 writes strings as UTF-16 bytes in little endian order, which takes fewer bytes for
+	// text of mostly CJK characters.
+	STRING_ENCODING_UTF16
+)
+
+// DefaultMaxDepth is the default max nesting depth of values in deserialization.
+const DefaultMaxDepth = 1000
+
+// Option configures a Config.
+type Option func(c *Config)
+
+func defaultConfig() Config {
+	return Config{
+		referenceTracking: false,
+		language:          XLANG,
+		compatibleMode:    SCHEMA_CONSISTENT,
+		maxDepth:          DefaultMaxDepth,
+	}
+}
+
+// NewConfig creates a Config from default options overridden by `opts`, an error is returned if the options
+// are invalid.
+func NewConfig(opts ...Option) (*Config, error) {
+	config := defaultConfig()
+	for _, opt := range opts {
+		opt(&config)
+	}
+	if err := config.validate(); err != nil {
+		return nil, err
+	}
+	return &config, nil
+}
+
+// With returns a new Config which has the options of `c` overridden by `opts`. `c` isn't changed.
+func (c *Config) With(opts ...Option) (*Config, error) {
+	return NewConfig(append([]Option{WithConfig(c)}, opts...)...)
+}
+
+func (c *Config) validate() error {
+	if c.language != XLANG && c.language != GO {
+		return fmt.Errorf("%d language is not supported", c.language)
+	}
+	if c.unexportedFields && c.language != GO {
+		return fmt.Errorf("unexported fields can only be serialized in GO language")
+	}
+	if c.compatibleMode != SCHEMA_CONSISTENT && c.compatibleMode != COMPATIBLE {
+		return fmt.Errorf("unknown compatible mode %d", c.compatibleMode)
+	}
+	if c.intEncoding != INT_ENCODING_FIXED && c.intEncoding != INT_ENCODING_VARINT {
+		return fmt.Errorf("unknown int encoding %d", c.intEncoding)
+	}
+	if c.intEncoding == INT_ENCODING_VARINT && c.language != GO {
+		return fmt.Errorf("varint int encoding can only be used in GO language")
+	}
+	if c.timestampUnit != TIME_UNIT_MICRO && c.timestampUnit != TIME_UNIT_NANO {
+		return fmt.Errorf("unknown timestamp unit %d", c.timestampUnit)
+	}
+	if c.stringEncoding != STRING_ENCODING_UTF8 && c.stringEncoding != STRING_ENCODING_UTF16 {
+		return fmt.Errorf("unknown string encoding %d", c.stringEncoding)
+	}
+	if c.maxDepth <= 0 {
+		return fmt.Errorf("max depth must be positive, but got %d", c.maxDepth)
+	}
+	if c.maxCollectionSize < 0 || c.maxBinarySize < 0 || c.maxTotalBytes < 0 {
+		return fmt.Errorf("deserialization limits can't be negative")
+	}
+	return nil
+}
+
+// ReferenceTracking returns whether shared and circular references are tracked.
+func (c *Config) ReferenceTracking() bool {
+	return c.referenceTracking
+}
+
+// Language returns the serialization protocol language.
+func (c *Config) Language() Language {
+	return c.language
+}
+
+// CompatibleMode returns how struct schemas are checked between peers.
+func (c *Config) CompatibleMode() CompatibleMode {
+	return c.compatibleMode
+}
+
+// UnexportedFields returns whether unexported struct fields are serialized.
+func (c *Config) UnexportedFields() bool {
+	return c.unexportedFields
+}
+
+// MaxDepth returns the max nesting depth of values in deserialization.
+func (c *Config) MaxDepth() int {
+	return c.maxDepth
+}
+
+// MaxCollectionSize returns the max number of elements of a deserialized collection, 0 means no limit.
+func (c *Config) MaxCollectionSize() int {
+	return c.maxCollectionSize
+}
+
+// MaxBinarySize returns the max size in bytes of a deserialized string or binary, 0 means no limit.
+func (c *Config) MaxBinarySize() int {
+	return c.maxBinarySize
+}
+
+// MaxTotalBytes returns the max bytes allocated for the values of one deserialization, 0 means no limit.
+func (c *Config) MaxTotalBytes() int {
+	return c.maxTotalBytes
+}
+
+// TypeChecker returns the checker of types read from the data, nil means all types are allowed.
+func (c *Config) TypeChecker() TypeChecker {
+	return c.typeChecker
+}
+
+// IntEncoding returns the encoding of int32/int64/int values.
+func (c *Config) IntEncoding() IntEncoding {
+	return c.intEncoding
+}
+
+// TimestampUnit returns the unit of TIMESTAMP values.
+func (c *Config) TimestampUnit() TimeUnit {
+	return c.timestampUnit
+}
+
+// StringEncoding returns the encoding of string values.
+func (c *Config) StringEncoding() StringEncoding {
+	return c.stringEncoding
+}
+
+// WithConfig copies all options from `config`, options after it override those options.
+func WithConfig(config *Config) Option {
+	return func(c *Config) {
+		*c = *config
+	}
+}
+
+// WithRefTracking sets whether to track shared and circular references. If disabled, shared objects will be
+// serialized multiple times and circular references will cause stack overflow. Default is false.
+func WithRefTracking(referenceTracking bool) Option {
+	return func(c *Config) {
+		c.referenceTracking = referenceTracking
+	}
+}
+
+// WithLanguage sets the serialization protocol language. XLANG is used for data exchanged with other languages,
+// golang specific values such as unsigned integers, complex numbers and untagged structs are written in the
+// native objects section of xlang data. GO is used for data which is only exchanged between golang, which
+// supports all golang values without the xlang header. Default is XLANG.
+func WithLanguage(language Language) Option {
+	return func(c *Config) {
+		c.language = language
+	}
+}
+
+// WithCompatibleMode sets how struct schemas are checked between peers. Default is SCHEMA_CONSISTENT.
+func WithCompatibleMode(mode CompatibleMode) Option {
+	return func(c *Config) {
+		c.compatibleMode = mode
+	}
+}
+
+// WithUnexportedFields sets whether to serialize unexported struct fields, which is only supported in GO
+// language. Default is false.
+func WithUnexportedFields(unexportedFields bool) Option {
+	return func(c *Config) {
+		c.unexportedFields = unexportedFields
+	}
+}
+
+// WithMaxDepth sets the max nesting depth of values in deserialization, which prevents deeply nested data
+// from overflowing the stack. Default is DefaultMaxDepth.
+func WithMaxDepth(maxDepth int) Option {
+	return func(c *Config) {
+		c.maxDepth = maxDepth
+	}
+}
+
+// WithMaxCollectionSize sets the max number of elements of a slice, array, map or set in deserialization.
+// Default is 0, which means no limit other than the size of the data.
+func WithMaxCollectionSize(maxCollectionSize int) Option {
+	return func(c *Config) {
+		c.maxCollectionSize = maxCollectionSize
+	}
+}
+
+// WithMaxBinarySize sets the max size in bytes of a string or binary in deserialization. Default is 0, which
+// means no limit other than the size of the data.
+func WithMaxBinarySize(maxBinarySize int) Option {
+	return func(c *Config) {
+		c.maxBinarySize = maxBinarySize
+	}
+}
+
+// WithMaxTotalBytes sets the max bytes allocated for the values of one deserialization. The bytes are estimated
+// by the memory size of values, such as the element size times the length of a slice. Default is 0, which means
+// no limit.
+func WithMaxTotalBytes(maxTotalBytes int) Option {
+	return func(c *Config) {
+		c.maxTotalBytes = maxTotalBytes
+	}
+}
+
+// WithTypeChecker sets the checker of type tags and golang type infos read from the data into interface values,
+// which should be set when deserializing untrusted data. Types identified by xlang type ids and predeclared
+// golang types such as `uint32` aren't checked. Default is nil, which allows all types.
+func WithTypeChecker(checker TypeChecker) Option {
+	return func(c *Config) {
+		c.typeChecker = checker
+	}
+}
+
+// WithIntEncoding sets the encoding of int32/int64/int values, including fields, elements of maps and values
+// in interfaces, but not primitive slices such as []int32. INT_ENCODING_VARINT can only be used in GO language,
+// since varint values are written with the golang specific type ids FURY_VAR_INT32/FURY_VAR_INT64, which can be
+// read by golang peers of any encoding, but the fields of a struct must be in the same encoding for peers. Struct
+// fields can override it by the `encoding` option of fury tag, such as `fury:",encoding=varint"`. Default is
+// INT_ENCODING_FIXED.
+func WithIntEncoding(encoding IntEncoding) Option {
+	return func(c *Config) {
+		c.intEncoding = encoding
+	}
+}
+
+// WithTimestampUnit sets the unit of TIMESTAMP values written for `time.Time`. Peers must use the same unit since
+// it's not written in the data. Default is TIME_UNIT_MICRO.
+func WithTimestampUnit(unit TimeUnit) Option {
+	return func(c *Config) {
+		c.timestampUnit = unit
+	}
+}
+
+// WithStringEncoding sets the encoding of string values, including fields, elements and enum names, but not meta
+// strings such as type tags. Peers must use the same encoding since it's not written in the data. Strings which
+// aren't valid UTF-8 are written in UTF-16 with the invalid bytes replaced by U+FFFD. Default is
+// STRING_ENCODING_UTF8.
+func WithStringEncoding(encoding StringEncoding) Option {
+	return func(c *Config) {
+		c.stringEncoding = encoding
+	}
+}
@@ -0,0 +1,79 @@
+// Licensed to the Apache Software Foundation (ASF) under one
+// or more contributor license agreements.  See the NOTICE file
+// distributed with this work for additional information
+// regarding copyright ownership.  The ASF licenses this file
+// to you under the Apache License, Version 2.0 (the
+// "License"); you may not use this file except in compliance
+// with the License.  You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package fury
+
+import (
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestConfig(t *testing.T) {
+	config, err := NewConfig()
+	require.Nil(t, err)
+	require.False(t, config.ReferenceTracking())
+	require.Equal(t, XLANG, config.Language())
+	require.Equal(t, SCHEMA_CONSISTENT, config.CompatibleMode())
+
+	derived, err := config.With(WithRefTracking(true), WithCompatibleMode(COMPATIBLE))
+	require.Nil(t, err)
+	require.True(t, derived.ReferenceTracking())
+	require.Equal(t, COMPATIBLE, derived.CompatibleMode())
+	// derive a config doesn't change the original one.
+	require.False(t, config.ReferenceTracking())
+
+	fury := NewFury(WithConfig(derived))
+	require.Equal(t, *derived, *fury.Config())
+	require.True(t, fury.refResolver.refTracking)
+
+	_, err = NewConfig(WithLanguage(JAVA))
+	require.Error(t, err)
+	_, err = config.With(WithCompatibleMode(100))
+	require.Error(t, err)
+	require.Panics(t, func() { NewFury(WithLanguage(JAVA)) })
+	require.Equal(t, DefaultMaxDepth, config.MaxDepth())
+	_, err = config.With(WithMaxDepth(0))
+	require.Error(t, err)
+	_, err = config.With(WithMaxTotalBytes(-1))
+	require.Error(t, err)
+	require.Equal(t, INT_ENCODING_FIXED, config.IntEncoding())
+	_, err = config.With(WithIntEncoding(2))
+	require.Error(t, err)
+	require.Equal(t, TIME_UNIT_MICRO, config.TimestampUnit())
+	_, err = config.With(WithTimestampUnit(2))
+	require.Error(t, err)
+	require.Equal(t, STRING_ENCODING_UTF8, config.StringEncoding())
+	_, err = config.With(WithStringEncoding(2))
+	require.Error(t, err)
+}
+
+func TestSetReferenceTracking(t *testing.T) {
+	fury := NewFury()
+	fury.SetReferenceTracking(true)
+	require.True(t, fury.Config().ReferenceTracking())
+	type A struct {
+		A1 *A
+	}
+	require.Nil(t, fury.RegisterTagType("example.A", A{}))
+	a := &A{}
+	a.A1 = a
+	bytes, err := fury.Marshal(a)
+	require.Nil(t, err)
+	var a1 *A
+	require.Nil(t, fury.Unmarshal(bytes, &a1))
+	require.Same(t, a1, a1.A1)
+}
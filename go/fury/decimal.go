@@ -0,0 +1,304 @@
+// Licensed to the Apache Software Foundation (ASF) under one
+// or more contributor license agreements.  See the NOTICE file
+// distributed with this work for additional information
+// regarding copyright ownership.  The ASF licenses this file
+// to you under the Apache License, Version 2.0 (the
+// "License"); you may not use this file except in compliance
+// with the License.  You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package fury
+
+import (
+	"fmt"
+	"math/big"
+	"reflect"
+	"strings"
+)
+
+// MaxDecimalScale is the max absolute scale of decimals which can be serialized. Converting a decimal to a rational
+// number computes `10^scale`, so the scale read from the data is bounded regardless of the limits of config.
+const MaxDecimalScale = 1 << 16
+
+// Decimal is an exact decimal number `Unscaled * 10^-Scale`, which is exchanged with Java BigDecimal and Python
+// Decimal. A nil Unscaled is zero.
+type Decimal struct {
+	Unscaled *big.Int
+	Scale    int32
+}
+
+// NewDecimal returns the decimal `unscaled * 10^-scale`.
+func NewDecimal(unscaled *big.Int, scale int32) Decimal {
+	return Decimal{Unscaled: unscaled, Scale: scale}
+}
+
+func (d Decimal) unscaled() *big.Int {
+	if d.Unscaled == nil {
+		return new(big.Int)
+	}
+	return d.Unscaled
+}
+
+// Rat returns the decimal as a rational number.
+func (d Decimal) Rat() *big.Rat {
+	rat := new(big.Rat).SetInt(d.unscaled())
+	if d.Scale == 0 {
+		return rat
+	}
+	scale := int64(d.Scale)
+	if scale < 0 {
+		scale = -scale
+	}
+	pow := new(big.Rat).SetInt(new(big.Int).Exp(big.NewInt(10), big.NewInt(scale), nil))
+	if d.Scale > 0 {
+		return rat.Quo(rat, pow)
+	}
+	return rat.Mul(rat, pow)
+}
+
+// String returns the decimal in plain notation such as `-12.340`.
+func (d Decimal) String() string {
+	unscaled := d.unscaled()
+	digits := new(big.Int).Abs(unscaled).String()
+	var sb strings.Builder
+	if unscaled.Sign() < 0 {
+		sb.WriteByte('-')
+	}
+	switch {
+	case d.Scale <= 0:
+		sb.WriteString(digits)
+		if unscaled.Sign() != 0 {
+			sb.WriteString(strings.Repeat("0", -int(d.Scale)))
+		}
+	case int(d.Scale) < len(digits):
+		point := len(digits) - int(d.Scale)
+		sb.WriteString(digits[:point])
+		sb.WriteByte('.')
+		sb.WriteString(digits[point:])
+	default:
+		sb.WriteString("0.")
+		sb.WriteString(strings.Repeat("0", int(d.Scale)-len(digits)))
+		sb.WriteString(digits)
+	}
+	return sb.String()
+}
+
+// decimalFromRat returns the decimal of `rat`, false is returned if `rat` can't be represented by a decimal,
+// which means its denominator has prime factors other than 2 and 5.
+func decimalFromRat(rat *big.Rat) (Decimal, bool) {
+	denom := new(big.Int).Set(rat.Denom())
+	twos := denom.TrailingZeroBits()
+	denom.Rsh(denom, twos)
+	var fives uint
+	five, quo, rem := big.NewInt(5), new(big.Int), new(big.Int)
+	for {
+		quo.QuoRem(denom, five, rem)
+		if rem.Sign() != 0 {
+			break
+		}
+		denom, quo = quo, denom
+		fives++
+	}
+	if !denom.IsInt64() || denom.Int64() != 1 {
+		return Decimal{}, false
+	}
+	// num / (2^twos * 5^fives) == num * 2^(scale-twos) * 5^(scale-fives) / 10^scale
+	scale := twos
+	if fives > scale {
+		scale = fives
+	}
+	if scale > MaxInt32 {
+		return Decimal{}, false
+	}
+	unscaled := new(big.Int).Lsh(rat.Num(), scale-twos)
+	unscaled.Mul(unscaled, new(big.Int).Exp(five, big.NewInt(int64(scale-fives)), nil))
+	return Decimal{Unscaled: unscaled, Scale: int32(scale)}, true
+}
+
+// writeDecimal writes `| scale | precision | length | unscaled bytes |`, the same as the BigDecimal serializer of
+// java. Scale, precision and length are var uint32, the unscaled value is big-endian two's complement.
+func writeDecimal(buf *ByteBuffer, d Decimal) error {
+	unscaled := d.unscaled()
+	precision := len(new(big.Int).Abs(unscaled).String())
+	bytes := bigIntToBytes(unscaled)
+	if len(bytes) > MaxInt32 {
+		return fmt.Errorf("too large decimal of %d bytes", len(bytes))
+	}
+	if d.Scale > MaxDecimalScale || d.Scale < -MaxDecimalScale {
+		return fmt.Errorf("decimal scale %d exceeds limit %d", d.Scale, MaxDecimalScale)
+	}
+	buf.WriteVarUint32(uint32(d.Scale))
+	buf.WriteVarUint32(uint32(precision))
+	buf.WriteLength(len(bytes))
+	buf.WriteBinary(bytes)
+	return nil
+}
+
+func readDecimal(f *Fury, buf *ByteBuffer) Decimal {
+	offset := buf.readerIndex
+	scale := int32(buf.ReadVarUint32())
+	if scale > MaxDecimalScale || scale < -MaxDecimalScale {
+		panic(&LimitError{Offset: offset, Limit: "decimal scale", Value: int(scale), Max: MaxDecimalScale})
+	}
+	// precision is decided by the unscaled value.
+	buf.ReadVarUint32()
+	length := f.readBinaryLength(buf)
+	f.allocate(buf, length)
+	return Decimal{Unscaled: bigIntFromBytes(buf.ReadBinary(length)), Scale: scale}
+}
+
+// decimalRat returns the decimal read from the data as a rational number, the memory of `10^scale` is recorded as
+// allocated since the scale in the data may be up to MaxDecimalScale.
+func decimalRat(f *Fury, buf *ByteBuffer, d Decimal) *big.Rat {
+	if d.Scale < 0 {
+		f.allocate(buf, -int(d.Scale)/2)
+	} else {
+		f.allocate(buf, int(d.Scale)/2)
+	}
+	return d.Rat()
+}
+
+// bigIntToBytes returns the minimal big-endian two's complement bytes of `x`, such as `BigInteger.toByteArray`
+// of java.
+func bigIntToBytes(x *big.Int) []byte {
+	if x.Sign() >= 0 {
+		bytes := x.Bytes()
+		if len(bytes) == 0 || bytes[0]&0x80 != 0 {
+			bytes = append([]byte{0}, bytes...)
+		}
+		return bytes
+	}
+	// -x - 1 is inverted to get the two's complement of x.
+	bytes := new(big.Int).Sub(new(big.Int).Neg(x), big.NewInt(1)).Bytes()
+	for i := range bytes {
+		bytes[i] = ^bytes[i]
+	}
+	if len(bytes) == 0 || bytes[0]&0x80 == 0 {
+		bytes = append([]byte{0xff}, bytes...)
+	}
+	return bytes
+}
+
+func bigIntFromBytes(bytes []byte) *big.Int {
+	if len(bytes) == 0 || bytes[0]&0x80 == 0 {
+		x := new(big.Int).SetBytes(bytes)
+		if x.Sign() == 0 {
+			// the same as `big.NewInt(0)`.
+			return new(big.Int)
+		}
+		return x
+	}
+	inverted := make([]byte, len(bytes))
+	for i, b := range bytes {
+		inverted[i] = ^b
+	}
+	x := new(big.Int).SetBytes(inverted)
+	return x.Neg(x.Add(x, big.NewInt(1)))
+}
+
+// isBigNumberType returns whether values of `type_` are serialized as decimals with golang type info.
+func isBigNumberType(type_ reflect.Type) bool {
+	return type_ == bigIntPtrType || type_ == bigRatPtrType || type_ == bigFloatPtrType
+}
+
+type decimalSerializer struct {
+}
+
+func (s decimalSerializer) TypeId() TypeId {
+	return DECIMAL
+}
+
+func (s decimalSerializer) Write(f *Fury, buf *ByteBuffer, value reflect.Value) error {
+	return writeDecimal(buf, value.Interface().(Decimal))
+}
+
+func (s decimalSerializer) Read(f *Fury, buf *ByteBuffer, type_ reflect.Type, value reflect.Value) error {
+	value.Set(reflect.ValueOf(readDecimal(f, buf)))
+	return nil
+}
+
+// bigIntSerializer serializes `*big.Int` as a decimal of scale 0, such as java BigInteger. The golang type info is
+// written to distinguish it from Decimal.
+type bigIntSerializer struct {
+}
+
+func (s bigIntSerializer) TypeId() TypeId {
+	return -DECIMAL
+}
+
+func (s bigIntSerializer) Write(f *Fury, buf *ByteBuffer, value reflect.Value) error {
+	return writeDecimal(buf, Decimal{Unscaled: value.Interface().(*big.Int)})
+}
+
+func (s bigIntSerializer) Read(f *Fury, buf *ByteBuffer, type_ reflect.Type, value reflect.Value) error {
+	d := readDecimal(f, buf)
+	if d.Scale == 0 {
+		value.Set(reflect.ValueOf(d.Unscaled))
+		return nil
+	}
+	rat := decimalRat(f, buf, d)
+	if !rat.IsInt() {
+		// the decimal isn't formatted, since it may have a lot of zeros.
+		return invalidDataError(buf, "decimal of scale %d can't be read into *big.Int", d.Scale)
+	}
+	value.Set(reflect.ValueOf(new(big.Int).Set(rat.Num())))
+	return nil
+}
+
+// bigRatSerializer serializes `*big.Rat` as a decimal, rational numbers which can't be represented by a decimal,
+// such as 1/3, can't be serialized.
+type bigRatSerializer struct {
+}
+
+func (s bigRatSerializer) TypeId() TypeId {
+	return -DECIMAL
+}
+
+func (s bigRatSerializer) Write(f *Fury, buf *ByteBuffer, value reflect.Value) error {
+	rat := value.Interface().(*big.Rat)
+	d, ok := decimalFromRat(rat)
+	if !ok {
+		return fmt.Errorf("rational number %s can't be serialized as decimal", rat)
+	}
+	return writeDecimal(buf, d)
+}
+
+func (s bigRatSerializer) Read(f *Fury, buf *ByteBuffer, type_ reflect.Type, value reflect.Value) error {
+	value.Set(reflect.ValueOf(decimalRat(f, buf, readDecimal(f, buf))))
+	return nil
+}
+
+// bigFloatSerializer serializes `*big.Float` as the exact decimal of its value, infinities can't be serialized.
+// The value is read with the precision of `big.Float.SetRat`, so binary fractions are read exactly.
+type bigFloatSerializer struct {
+}
+
+func (s bigFloatSerializer) TypeId() TypeId {
+	return -DECIMAL
+}
+
+func (s bigFloatSerializer) Write(f *Fury, buf *ByteBuffer, value reflect.Value) error {
+	float := value.Interface().(*big.Float)
+	if float.IsInf() {
+		return fmt.Errorf("infinite float %s can't be serialized as decimal", float)
+	}
+	rat, _ := float.Rat(nil)
+	d, ok := decimalFromRat(rat)
+	if !ok {
+		return fmt.Errorf("float %s can't be serialized as decimal", float)
+	}
+	return writeDecimal(buf, d)
+}
+
+func (s bigFloatSerializer) Read(f *Fury, buf *ByteBuffer, type_ reflect.Type, value reflect.Value) error {
+	value.Set(reflect.ValueOf(new(big.Float).SetRat(decimalRat(f, buf, readDecimal(f, buf)))))
+	return nil
+}
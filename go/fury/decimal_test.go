@@ -0,0 +1,126 @@
+// Licensed to the Apache Software Foundation (ASF) under one
+// or more contributor license agreements.  See the NOTICE file
+// distributed with this work for additional information
+// regarding copyright ownership.  The ASF licenses this file
+// to you under the Apache License, Version 2.0 (the
+// "License"); you may not use this file except in compliance
+// with the License.  You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package fury
+
+import (
+	"github.com/stretchr/testify/require"
+	"math/big"
+	"testing"
+)
+
+func TestBigIntBytes(t *testing.T) {
+	// bytes of java `BigInteger.toByteArray`.
+	for value, bytes := range map[int64][]byte{
+		0: {0}, 1: {1}, 127: {0x7f}, 128: {0, 0x80}, 255: {0, 0xff}, 256: {1, 0},
+		-1: {0xff}, -128: {0x80}, -129: {0xff, 0x7f}, -256: {0xff, 0}, -257: {0xfe, 0xff},
+	} {
+		require.Equal(t, bytes, bigIntToBytes(big.NewInt(value)), value)
+		require.Equal(t, value, bigIntFromBytes(bytes).Int64())
+	}
+	for _, s := range []string{"123456789012345678901234567890", "-123456789012345678901234567890"} {
+		x, _ := new(big.Int).SetString(s, 10)
+		require.Equal(t, x, bigIntFromBytes(bigIntToBytes(x)))
+	}
+}
+
+func TestDecimalString(t *testing.T) {
+	for expected, d := range map[string]Decimal{
+		"0":       {},
+		"-12.345": NewDecimal(big.NewInt(-12345), 3),
+		"0.005":   NewDecimal(big.NewInt(5), 3),
+		"-0.05":   NewDecimal(big.NewInt(-5), 2),
+		"12300":   NewDecimal(big.NewInt(123), -2),
+		"1.0":     NewDecimal(big.NewInt(10), 1),
+	} {
+		require.Equal(t, expected, d.String())
+		rat, ok := new(big.Rat).SetString(expected)
+		require.True(t, ok)
+		require.Equal(t, 0, rat.Cmp(d.Rat()), expected)
+	}
+}
+
+func TestDecimalFromRat(t *testing.T) {
+	for rat, expected := range map[string]Decimal{
+		"7":    NewDecimal(big.NewInt(7), 0),
+		"1/8":  NewDecimal(big.NewInt(125), 3),
+		"3/20": NewDecimal(big.NewInt(15), 2),
+		"-5/2": NewDecimal(big.NewInt(-25), 1),
+	} {
+		r, _ := new(big.Rat).SetString(rat)
+		d, ok := decimalFromRat(r)
+		require.True(t, ok)
+		require.Equal(t, expected, d, rat)
+	}
+	_, ok := decimalFromRat(big.NewRat(1, 3))
+	require.False(t, ok)
+}
+
+func TestSerializeDecimal(t *testing.T) {
+	type Invoice struct {
+		Amount   Decimal
+		Total    *big.Int
+		Rate     *big.Rat
+		Exchange *big.Float
+	}
+	huge, _ := new(big.Int).SetString("-123456789012345678901234567890", 10)
+	invoice := Invoice{Amount: NewDecimal(big.NewInt(-12345), 2), Total: huge, Rate: big.NewRat(3, 8)}
+	for _, language := range []Language{XLANG, GO} {
+		fury := NewFury(WithLanguage(language))
+		require.Nil(t, fury.RegisterTagType("example.Invoice", Invoice{}))
+		bytes, err := fury.Marshal(invoice.Amount)
+		require.Nil(t, err)
+		// flag, type id, scale, precision, length and unscaled bytes.
+		require.Equal(t, []byte{0xff, byte(DECIMAL), 0, 2, 5, 2, 0xcf, 0xc7}, bytes[len(bytes)-8:])
+		for _, value := range []interface{}{invoice.Amount, NewDecimal(big.NewInt(0), 1), huge, big.NewInt(0),
+			invoice.Rate, []interface{}{invoice.Amount, huge}, invoice} {
+			serde(t, fury, value)
+		}
+		float := big.NewFloat(-1.25)
+		bytes, err = fury.Marshal(float)
+		require.Nil(t, err)
+		newFloat, err := DeserializeWith[*big.Float](fury, bytes)
+		require.Nil(t, err)
+		require.Equal(t, 0, float.Cmp(newFloat))
+		// integral decimals can be read into *big.Int.
+		bytes, err = fury.Marshal(NewDecimal(big.NewInt(12), -2))
+		require.Nil(t, err)
+		integer, err := DeserializeWith[*big.Int](fury, bytes)
+		require.Nil(t, err)
+		require.Equal(t, big.NewInt(1200), integer)
+		bytes, err = fury.Marshal(big.NewRat(1, 2))
+		require.Nil(t, err)
+		_, err = DeserializeWith[*big.Int](fury, bytes)
+		require.Error(t, err)
+
+		// scales in the data are bounded.
+		bytes, err = fury.Marshal(NewDecimal(big.NewInt(1), MaxDecimalScale))
+		require.Nil(t, err)
+		_, err = DeserializeWith[*big.Rat](fury, bytes)
+		require.Nil(t, err)
+		_, err = fury.Marshal(NewDecimal(big.NewInt(1), -MaxDecimalScale-1))
+		require.Error(t, err)
+		buf := NewByteBuffer(nil)
+		buf.WriteVarUint32(MaxInt32)
+		require.PanicsWithError(t, (&LimitError{Limit: "decimal scale", Value: MaxInt32, Max: MaxDecimalScale}).Error(),
+			func() { readDecimal(fury, buf) })
+		_, err = fury.Marshal(big.NewRat(1, 3))
+		require.Error(t, err)
+		_, err = fury.Marshal(new(big.Float).SetInf(false))
+		require.Error(t, err)
+	}
+}
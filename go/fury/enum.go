@@ -0,0 +1,129 @@
+// Licensed to the Apache Software Foundation (ASF) under one
+// or more contributor license agreements.  See the NOTICE file
+// distributed with this work for additional information
+// regarding copyright ownership.  The ASF licenses this file
+// to you under the Apache License, Version 2.0 (the
+// "License"); you may not use this file except in compliance
+// with the License.  You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package fury
+
+import (
+	"fmt"
+	"reflect"
+)
+
+// enumSerializer serializes a named integer or string type registered by `RegisterEnum`, see `enum` in xlang
+// serialization spec. `names` are the names of the enum values in ordinal order. The ordinal of an integer value is
+// the value itself, and the ordinal of a string value is the index of the value in `names`. Values are written as
+// var uint32 ordinals, or as names if `byName` is true.
+type enumSerializer struct {
+	typeTag  string
+	type_    reflect.Type
+	names    []string
+	ordinals map[string]int
+	byName   bool
+}
+
+func newEnumSerializer(type_ reflect.Type, tag string, names []string, byName bool) (*enumSerializer, error) {
+	if type_ == nil || type_.PkgPath() == "" {
+		return nil, fmt.Errorf("enum type %v must be a named type", type_)
+	}
+	if len(names) == 0 {
+		return nil, fmt.Errorf("enum type %s has no names", type_)
+	}
+	overflow := false
+	switch type_.Kind() {
+	case reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64, reflect.Int:
+		overflow = reflect.Zero(type_).OverflowInt(int64(len(names) - 1))
+	case reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uint:
+		overflow = reflect.Zero(type_).OverflowUint(uint64(len(names) - 1))
+	case reflect.String:
+	default:
+		return nil, fmt.Errorf("enum type %s must be an integer or string type", type_)
+	}
+	if overflow {
+		return nil, fmt.Errorf("enum type %s can't hold %d values", type_, len(names))
+	}
+	ordinals := make(map[string]int, len(names))
+	for i, name := range names {
+		if _, ok := ordinals[name]; ok || name == "" {
+			return nil, fmt.Errorf("enum type %s has invalid name %q", type_, name)
+		}
+		ordinals[name] = i
+	}
+	return &enumSerializer{typeTag: tag, type_: type_, names: names, ordinals: ordinals, byName: byName}, nil
+}
+
+func (s *enumSerializer) TypeId() TypeId {
+	return FURY_TYPE_TAG
+}
+
+// ordinal returns the ordinal of `value`, -1 is returned if `value` isn't a value of the enum.
+func (s *enumSerializer) ordinal(value reflect.Value) int {
+	var ordinal int64 = -1
+	switch value.Kind() {
+	case reflect.String:
+		if i, ok := s.ordinals[value.String()]; ok {
+			ordinal = int64(i)
+		}
+	case reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uint:
+		if v := value.Uint(); v < uint64(len(s.names)) {
+			ordinal = int64(v)
+		}
+	default:
+		ordinal = value.Int()
+	}
+	if ordinal < 0 || ordinal >= int64(len(s.names)) {
+		return -1
+	}
+	return int(ordinal)
+}
+
+func (s *enumSerializer) Write(f *Fury, buf *ByteBuffer, value reflect.Value) error {
+	ordinal := s.ordinal(value)
+	if ordinal < 0 {
+		return fmt.Errorf("unknown value %v of enum %s", value, s.type_)
+	}
+	if s.byName {
+		return writeString(f, buf, s.names[ordinal])
+	}
+	buf.WriteVarUint32(uint32(ordinal))
+	return nil
+}
+
+func (s *enumSerializer) Read(f *Fury, buf *ByteBuffer, type_ reflect.Type, value reflect.Value) error {
+	var ordinal int
+	if s.byName {
+		name := readString(f, buf)
+		i, ok := s.ordinals[name]
+		if !ok {
+			return invalidDataError(buf, "unknown name %s of enum %s", name, s.type_)
+		}
+		ordinal = i
+	} else {
+		v := buf.ReadVarUint32()
+		if v >= uint32(len(s.names)) {
+			return invalidDataError(buf, "unknown ordinal %d of enum %s", v, s.type_)
+		}
+		ordinal = int(v)
+	}
+	switch value.Kind() {
+	case reflect.String:
+		value.SetString(s.names[ordinal])
+	case reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uint:
+		value.SetUint(uint64(ordinal))
+	default:
+		value.SetInt(int64(ordinal))
+	}
+	return nil
+}
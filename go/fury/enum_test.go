@@ -0,0 +1,142 @@
+// Licensed to the Apache Software Foundation (ASF) under one
+// or more contributor license agreements.  See the NOTICE file
+// distributed with this work for additional information
+// regarding copyright ownership.  The ASF licenses this file
+// to you under the Apache License, Version 2.0 (the
+// "License"); you may not use this file except in compliance
+// with the License.  You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package fury
+
+import (
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+type Status int32
+
+const (
+	StatusPending Status = iota
+	StatusActive
+	StatusClosed
+)
+
+type Color string
+
+func TestSerializeEnum(t *testing.T) {
+	type Ticket struct {
+		Status  Status
+		Color   Color
+		History []Status
+	}
+	ticket := Ticket{Status: StatusActive, Color: "GREEN", History: []Status{StatusPending, StatusActive}}
+	for _, mode := range []CompatibleMode{SCHEMA_CONSISTENT, COMPATIBLE} {
+		fury := NewFury(WithCompatibleMode(mode))
+		require.Nil(t, fury.RegisterEnum("example.Status", StatusPending, "PENDING", "ACTIVE", "CLOSED"))
+		require.Nil(t, fury.RegisterNamedEnum("example.Color", Color(""), "RED", "GREEN"))
+		require.Nil(t, fury.RegisterTagType("example.Ticket", Ticket{}))
+		bytes, err := fury.Marshal(StatusClosed)
+		require.Nil(t, err)
+		// type tag and ordinal.
+		tag, err := typeMetaStringEncoder.Encode("example.Status")
+		require.Nil(t, err)
+		require.Equal(t, append(tag.GetEncodedBytes(), 2), bytes[len(bytes)-len(tag.GetEncodedBytes())-1:])
+		bytes, err = fury.Marshal(Color("GREEN"))
+		require.Nil(t, err)
+		require.Equal(t, append([]byte{5}, "GREEN"...), bytes[len(bytes)-6:])
+		for _, value := range []interface{}{StatusPending, StatusClosed, Color("RED"), ticket,
+			[]interface{}{StatusActive, Color("GREEN")}, map[Status]Color{StatusActive: "RED"}} {
+			serde(t, fury, value)
+		}
+		_, err = fury.Marshal(Status(3))
+		require.Error(t, err)
+		_, err = fury.Marshal(Color("BLUE"))
+		require.Error(t, err)
+	}
+}
+
+func TestEnumPeerChanges(t *testing.T) {
+	writer := NewFury()
+	require.Nil(t, writer.RegisterEnum("example.Status", StatusPending, "PENDING", "ACTIVE", "CLOSED"))
+	require.Nil(t, writer.RegisterNamedEnum("example.Color", Color(""), "RED", "GREEN"))
+	reader := NewFury()
+	// the reader doesn't know CLOSED yet, and declares colors in another order.
+	require.Nil(t, reader.RegisterEnum("example.Status", StatusPending, "PENDING", "ACTIVE"))
+	require.Nil(t, reader.RegisterNamedEnum("example.Color", Color(""), "GREEN", "RED"))
+	bytes, err := writer.Marshal(StatusActive)
+	require.Nil(t, err)
+	var status Status
+	require.Nil(t, reader.Unmarshal(bytes, &status))
+	require.Equal(t, StatusActive, status)
+	bytes, err = writer.Marshal(StatusClosed)
+	require.Nil(t, err)
+	require.Error(t, reader.Unmarshal(bytes, &status))
+	bytes, err = writer.Marshal(Color("RED"))
+	require.Nil(t, err)
+	var color interface{}
+	require.Nil(t, reader.Unmarshal(bytes, &color))
+	require.Equal(t, Color("RED"), color)
+}
+
+func TestSkipEnumFields(t *testing.T) {
+	type V1 struct {
+		Name    string
+		Status  Status
+		Color   *Color
+		History []Status
+		Colors  map[Color]Status
+	}
+	type V2 struct {
+		Name string
+	}
+	color := Color("GREEN")
+	v1 := V1{Name: "ticket", Status: StatusClosed, Color: &color, History: []Status{StatusPending},
+		Colors: map[Color]Status{"RED": StatusActive}}
+	for _, referenceTracking := range []bool{false, true} {
+		writer := NewFury(WithRefTracking(referenceTracking), WithCompatibleMode(COMPATIBLE))
+		require.Nil(t, writer.RegisterEnum("example.Status", StatusPending, "PENDING", "ACTIVE", "CLOSED"))
+		require.Nil(t, writer.RegisterNamedEnum("example.Color", Color(""), "RED", "GREEN"))
+		require.Nil(t, writer.RegisterTagType("example.Ticket", V1{}))
+		bytes, err := writer.Marshal(v1)
+		require.Nil(t, err)
+		// enum fields are skipped whether the enums are registered in reader or not.
+		for _, registered := range []bool{false, true} {
+			reader := NewFury(WithRefTracking(referenceTracking), WithCompatibleMode(COMPATIBLE))
+			if registered {
+				require.Nil(t, reader.RegisterEnum("example.Status", StatusPending, "PENDING", "ACTIVE"))
+				require.Nil(t, reader.RegisterNamedEnum("example.Color", Color(""), "GREEN", "RED"))
+			}
+			require.Nil(t, reader.RegisterTagType("example.Ticket", V2{}))
+			var v2 V2
+			require.Nil(t, reader.Unmarshal(bytes, &v2))
+			require.Equal(t, V2{Name: "ticket"}, v2)
+		}
+	}
+}
+
+func TestRegisterEnumErrors(t *testing.T) {
+	type Level int8
+	type Point struct{}
+	fury := NewFury()
+	require.Error(t, fury.RegisterEnum("example.Int", int32(0), "A"))
+	require.Error(t, fury.RegisterEnum("example.Point", Point{}, "A"))
+	require.Error(t, fury.RegisterEnum("example.Level", Level(0)))
+	require.Error(t, fury.RegisterEnum("example.Level", Level(0), "A", "A"))
+	names := make([]string, 129)
+	for i := range names {
+		names[i] = string(rune('A'+i/26)) + string(rune('A'+i%26))
+	}
+	require.Error(t, fury.RegisterEnum("example.Level", Level(0), names...))
+	require.Nil(t, fury.RegisterEnum("example.Level", Level(0), names[:128]...))
+	require.Error(t, fury.RegisterEnum("example.Status", Level(0), "A"))
+	require.Error(t, fury.RegisterEnum("example.Level", StatusPending, "A"))
+}
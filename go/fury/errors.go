@@ -0,0 +1,123 @@
+// Licensed to the Apache Software Foundation (ASF) under one
+// or more contributor license agreements.  See the NOTICE file
+// distributed with this work for additional information
+// regarding copyright ownership.  The ASF licenses this file
+// to you under the Apache License, Version 2.0 (the
+// "License"); you may not use this file except in compliance
+// with the License.  You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package fury
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ErrBufferUnderflow is reported when the data ends before a value is fully read, which means the data
+// is truncated or corrupted.
+var ErrBufferUnderflow = errors.New("fury: buffer underflow")
+
+// ErrInvalidData is reported when the data is inconsistent with the protocol or the value it's read into,
+// such as unknown flags, out of range ids and mismatched types.
+var ErrInvalidData = errors.New("fury: invalid data")
+
+// ErrLimitExceeded is reported when the data exceeds a deserialization limit of Config, such as max depth.
+var ErrLimitExceeded = errors.New("fury: limit exceeded")
+
+// ErrDisallowedType is reported when a type read from the data is rejected by the TypeChecker of Config.
+var ErrDisallowedType = errors.New("fury: disallowed type")
+
+// BufferUnderflowError describes a read which exceeds the end of a buffer. It matches ErrBufferUnderflow
+// by `errors.Is`.
+type BufferUnderflowError struct {
+	// Offset is the reader index where the read starts.
+	Offset int
+	// Size is the number of bytes to read.
+	Size int
+	// Remaining is the number of bytes left in the buffer.
+	Remaining int
+}
+
+func (e *BufferUnderflowError) Error() string {
+	return fmt.Sprintf("fury: buffer underflow: read %d bytes at offset %d, but only %d bytes remaining",
+		e.Size, e.Offset, e.Remaining)
+}
+
+func (e *BufferUnderflowError) Unwrap() error {
+	return ErrBufferUnderflow
+}
+
+// InvalidDataError describes invalid data found at Offset. It matches ErrInvalidData by `errors.Is`.
+type InvalidDataError struct {
+	Offset int
+	Msg    string
+}
+
+func (e *InvalidDataError) Error() string {
+	return fmt.Sprintf("fury: invalid data at offset %d: %s", e.Offset, e.Msg)
+}
+
+func (e *InvalidDataError) Unwrap() error {
+	return ErrInvalidData
+}
+
+// LimitError describes a deserialization limit exceeded at Offset. It matches ErrLimitExceeded by `errors.Is`.
+type LimitError struct {
+	Offset int
+	// Limit is the name of the limit, such as "depth" and "collection size".
+	Limit string
+	Value int
+	Max   int
+}
+
+func (e *LimitError) Error() string {
+	return fmt.Sprintf("fury: %s %d exceeds limit %d at offset %d", e.Limit, e.Value, e.Max, e.Offset)
+}
+
+func (e *LimitError) Unwrap() error {
+	return ErrLimitExceeded
+}
+
+// DisallowedTypeError describes a type rejected by TypeChecker. It matches ErrDisallowedType by `errors.Is`.
+type DisallowedTypeError struct {
+	// Name is the type tag or golang type info checked by TypeChecker.
+	Name string
+}
+
+func (e *DisallowedTypeError) Error() string {
+	return fmt.Sprintf("fury: type %s is not allowed to be deserialized", e.Name)
+}
+
+func (e *DisallowedTypeError) Unwrap() error {
+	return ErrDisallowedType
+}
+
+func invalidDataError(buffer *ByteBuffer, format string, args ...interface{}) error {
+	return &InvalidDataError{Offset: buffer.readerIndex, Msg: fmt.Sprintf(format, args...)}
+}
+
+// recoverReadError converts a panic raised when reading the data to an error. Buffer reads panic with
+// *BufferUnderflowError instead of returning errors to keep the reading fast path simple, and readers panic with
+// *InvalidDataError and *LimitError likewise. Other panics are bugs rather than invalid data, so they are raised
+// again.
+func recoverReadError(r interface{}) error {
+	switch r := r.(type) {
+	case *BufferUnderflowError:
+		return r
+	case *InvalidDataError:
+		return r
+	case *LimitError:
+		return r
+	default:
+		panic(r)
+	}
+}
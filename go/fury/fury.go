@@ -19,25 +19,41 @@ package fury
 
 import (
 	"encoding/binary"
+	"errors"
 	"fmt"
 	"reflect"
 	"sync"
 )
 
-func NewFury(referenceTracking bool) *Fury {
+// NewFury creates a Fury with default options overridden by `opts`. Panics if the options are invalid, use
+// `NewConfig` to validate options first if they are not constants. For example:
+//
+//	fury := NewFury(WithRefTracking(true), WithCompatibleMode(COMPATIBLE))
+//	// share the same config between Fury instances.
+//	other := NewFury(WithConfig(fury.Config()))
+func NewFury(opts ...Option) *Fury {
+	config, err := NewConfig(opts...)
+	if err != nil {
+		panic(err)
+	}
 	fury := &Fury{
+		config:            config,
 		typeResolver:      newTypeResolver(),
-		refResolver:       newRefResolver(referenceTracking),
-		referenceTracking: referenceTracking,
-		language:          XLANG,
+		refResolver:       newRefResolver(config.referenceTracking),
+		referenceTracking: config.referenceTracking,
+		language:          config.language,
+		compatibleMode:    config.compatibleMode,
 		buffer:            NewByteBuffer(nil),
 	}
+	fury.typeResolver.typeChecker = config.typeChecker
+	fury.typeResolver.setIntEncoding(config.intEncoding)
+	fury.typeResolver.registerGlobalTypes()
 	return fury
 }
 
 var furyPool = sync.Pool{
 	New: func() interface{} {
-		return NewFury(true)
+		return NewFury(WithRefTracking(true))
 	},
 }
 
@@ -49,15 +65,19 @@ func PutFury(fury *Fury) {
 	furyPool.Put(fury)
 }
 
-// Marshal returns the MessagePack encoding of v.
+// Marshal returns the fury encoding of v. The returned bytes are owned by the caller.
 func Marshal(v interface{}) ([]byte, error) {
 	fury := GetFury()
-	err := fury.Serialize(nil, v, nil)
-	data := fury.buffer.GetByteSlice(0, fury.buffer.writerIndex)
+	data, err := fury.Marshal(v)
+	PutFury(fury)
+	return data, err
+}
+
+// MarshalAppend appends the fury encoding of v to dst and returns the extended buffer.
+func MarshalAppend(dst []byte, v interface{}) ([]byte, error) {
+	fury := GetFury()
+	data, err := fury.MarshalAppend(dst, v)
 	PutFury(fury)
-	if err != nil {
-		return nil, err
-	}
 	return data, err
 }
 
@@ -70,6 +90,34 @@ func Unmarshal(data []byte, v interface{}) error {
 	return err
 }
 
+// Serialize returns the fury encoding of v, which is the same as Marshal except that the static type of v is kept.
+func Serialize[T any](v T) ([]byte, error) {
+	fury := GetFury()
+	data, err := SerializeWith(fury, v)
+	PutFury(fury)
+	return data, err
+}
+
+// Deserialize decodes the fury-encoded data into a value of type T, so there is no pointer target to get wrong.
+func Deserialize[T any](data []byte) (T, error) {
+	fury := GetFury()
+	v, err := DeserializeWith[T](fury, data)
+	PutFury(fury)
+	return v, err
+}
+
+// SerializeWith returns the fury encoding of v by `fury`, which can be configured and registered with types.
+func SerializeWith[T any](fury *Fury, v T) ([]byte, error) {
+	return fury.Marshal(v)
+}
+
+// DeserializeWith decodes the fury-encoded data into a value of type T by `fury`.
+func DeserializeWith[T any](fury *Fury, data []byte) (T, error) {
+	var v T
+	err := fury.Unmarshal(data, &v)
+	return v, err
+}
+
 // BufferCallback to check whether write buffer in band. If the callback returns false, the given buffer is
 // out-of-band; otherwise the buffer is serialized in-band, i.e. inside the serialized stream.
 type BufferCallback = func(o BufferObject) bool
@@ -95,40 +143,137 @@ const (
 
 const MAGIC_NUMBER int16 = 0x62D4
 
+// CompatibleMode controls how struct schemas are checked between peers.
+type CompatibleMode = uint8
+
+const (
+	// SCHEMA_CONSISTENT requires both peers to have the same struct definitions. Only a hash of the fields
+	// is written, and a mismatch is reported as an error.
+	SCHEMA_CONSISTENT CompatibleMode = iota
+	// COMPATIBLE writes the fields meta of registered structs, so peers can add, remove or reorder fields.
+	// Fields are matched by name when reading, unknown fields are skipped and missing fields keep zero value.
+	COMPATIBLE
+)
+
 type Fury struct {
+	config            *Config
 	typeResolver      *typeResolver
 	refResolver       *RefResolver
 	referenceTracking bool
 	language          Language
+	compatibleMode    CompatibleMode
 	bufferCallback    BufferCallback
 	peerLanguage      Language
 	buffer            *ByteBuffer
 	buffers           []*ByteBuffer
+	// appendBuffer wraps the caller's buffer in `MarshalAppend`.
+	appendBuffer ByteBuffer
+	// golang specific objects in xlang serialization, see `writeNativeObjects`.
+	nativeObjects     []reflect.Value
+	nativeReadObjects []reflect.Value
+	nativeSection     bool
+	// current nesting depth and allocated bytes of deserialization, which are checked against the limits of config.
+	depth          int
+	allocatedBytes int
 }
 
 func (f *Fury) RegisterTagType(tag string, v interface{}) error {
 	return f.typeResolver.RegisterTypeTag(reflect.TypeOf(v), tag)
 }
 
+// RegisterEnum registers the named integer or string type of `v` as an enum of `tag`, such as `type Status int32`
+// with constants declared by iota. `names` are the names of the enum values in ordinal order, which are the same
+// as the declarations of the java or python enum. The ordinal of an integer value is the value itself, and the
+// ordinal of a string value is its index in `names`. Values are written as ordinals, serializing a value which
+// isn't in `names` or deserializing an unknown ordinal returns an error.
+func (f *Fury) RegisterEnum(tag string, v interface{}, names ...string) error {
+	return f.typeResolver.RegisterEnum(reflect.TypeOf(v), tag, names, false)
+}
+
+// RegisterNamedEnum registers an enum as `RegisterEnum`, but values are written as names, so peers can reorder
+// their enum values. Deserializing an unknown name returns an error.
+func (f *Fury) RegisterNamedEnum(tag string, v interface{}, names ...string) error {
+	return f.typeResolver.RegisterEnum(reflect.TypeOf(v), tag, names, true)
+}
+
+// RegisterImplementations registers tagged structs as the implementations of the interface pointed to by `iface`,
+// such as `RegisterImplementations((*Shape)(nil), Circle{}, &Square{})`, so interface fields and slice elements
+// of the interface are read as the values or pointers registered. Structs in data of peers are tagged as pointers,
+// which are read as pointers into interfaces by default. Values of implementations registered by values are
+// written with their type tags, which can be read by peers.
+func (f *Fury) RegisterImplementations(iface interface{}, impls ...interface{}) error {
+	ifaceType := reflect.TypeOf(iface)
+	if ifaceType == nil || ifaceType.Kind() != reflect.Ptr {
+		return fmt.Errorf("interface should be passed as a nil pointer such as `(*Shape)(nil)`, but got %T", iface)
+	}
+	implTypes := make([]reflect.Type, len(impls))
+	for i, impl := range impls {
+		if implTypes[i] = reflect.TypeOf(impl); implTypes[i] == nil {
+			return fmt.Errorf("implementation of %s can't be nil", ifaceType.Elem())
+		}
+	}
+	return f.typeResolver.RegisterImplementations(ifaceType.Elem(), implTypes)
+}
+
+// RegisterType registers the type of `v` by its golang type name, which is needed to deserialize values of
+// named types and untagged structs into interface values. Marshaller types are registered by their ext ids too,
+// so that values of the ext id are deserialized into interface values as the type of `v`.
+func (f *Fury) RegisterType(v interface{}) error {
+	return f.typeResolver.RegisterType(reflect.TypeOf(v))
+}
+
+// RegisterSerializer registers a custom serializer for the type of `v`, which must be called before values of the
+// type are serialized. Values are identified in the data by the type id of the serializer: a positive id which
+// isn't used by other types identifies the type itself, and the golang type info is written for a negative id or
+// NotSupportCrossLanguage. FURY_TYPE_TAG and EXTENSION are reserved for tagged structs and Marshaller types.
+func (f *Fury) RegisterSerializer(v interface{}, s Serializer) error {
+	type_ := reflect.TypeOf(v)
+	if type_ == nil {
+		return fmt.Errorf("can't register serializer %T for nil", s)
+	}
+	if id := s.TypeId(); id == FURY_TYPE_TAG || id == EXTENSION || id == -EXTENSION {
+		return fmt.Errorf("type id %d of serializer %T is reserved", id, s)
+	}
+	return f.typeResolver.RegisterSerializer(type_, s)
+}
+
+// Marshal returns the fury encoding of v. The returned bytes are owned by the caller, use `MarshalAppend` to
+// reuse a caller-managed buffer.
 func (f *Fury) Marshal(v interface{}) ([]byte, error) {
 	err := f.Serialize(nil, v, nil)
 	if err != nil {
 		return nil, err
 	}
-	return f.buffer.GetByteSlice(0, f.buffer.writerIndex), nil
+	// copy the data since `f.buffer` will be reused by the next serialization.
+	return append([]byte(nil), f.buffer.GetByteSlice(0, f.buffer.writerIndex)...), nil
+}
+
+// MarshalAppend appends the fury encoding of v to dst and returns the extended buffer. No allocation happens
+// if dst has enough capacity. If an error is returned, the returned buffer contains partial data after len(dst).
+func (f *Fury) MarshalAppend(dst []byte, v interface{}) ([]byte, error) {
+	buffer := &f.appendBuffer
+	buffer.data = dst[:cap(dst)]
+	buffer.writerIndex = len(dst)
+	err := f.Serialize(buffer, v, nil)
+	data := buffer.data[:buffer.writerIndex]
+	// don't retain the caller's buffer.
+	buffer.data = nil
+	return data, err
 }
 
 func (f *Fury) Serialize(buf *ByteBuffer, v interface{}, callback BufferCallback) error {
 	defer f.resetWrite()
 	f.bufferCallback = callback
+	f.typeResolver.registerGlobalTypes()
 	buffer := buf
 	if buffer == nil {
 		buffer = f.buffer
 		buffer.writerIndex = 0
 	}
+	start := buffer.writerIndex
 	if f.language == XLANG {
 		buffer.WriteInt16(MAGIC_NUMBER)
-	} else {
+	} else if f.language != GO {
 		return fmt.Errorf("%d language is not supported", f.language)
 	}
 	var bitmap byte = 0
@@ -141,8 +286,6 @@ func (f *Fury) Serialize(buf *ByteBuffer, v interface{}, callback BufferCallback
 	// set reader as x_lang.
 	if f.language == XLANG {
 		bitmap |= isCrossLanguageFlag
-	} else {
-		return fmt.Errorf("%d language is not supported", f.language)
 	}
 	if callback != nil {
 		bitmap |= isOutOfBandFlag
@@ -151,16 +294,40 @@ func (f *Fury) Serialize(buf *ByteBuffer, v interface{}, callback BufferCallback
 		return err
 	}
 	if f.language != XLANG {
-		return fmt.Errorf("%d language is not supported", f.language)
+		return f.Write(buffer, v)
 	} else {
 		if err := buffer.WriteByte(GO); err != nil {
 			return err
 		}
+		nativeObjectsOffset := buffer.writerIndex
 		buffer.WriteInt32(0) // preserve 4-byte for nativeObjects start offsets.
 		buffer.WriteInt32(0)
 		if err := f.Write(buffer, v); err != nil {
 			return err
 		}
+		return f.writeNativeObjects(buffer, start, nativeObjectsOffset)
+	}
+}
+
+// writeNativeObjects writes golang specific objects which can't be serialized by xlang protocol after the
+// xlang objects, so peers of other languages can skip them. Native objects are written with separate
+// reference and meta string states, which makes them can be read before the xlang objects.
+func (f *Fury) writeNativeObjects(buffer *ByteBuffer, start int, nativeObjectsOffset int) error {
+	if len(f.nativeObjects) == 0 {
+		return nil
+	}
+	buffer.PutInt32(nativeObjectsOffset, int32(buffer.writerIndex-start))
+	buffer.PutInt32(nativeObjectsOffset+4, int32(len(f.nativeObjects)))
+	f.typeResolver.resetWrite()
+	f.refResolver.resetWrite()
+	f.nativeSection = true
+	defer func() {
+		f.nativeSection = false
+	}()
+	for _, value := range f.nativeObjects {
+		if err := f.WriteReferencable(buffer, value); err != nil {
+			return err
+		}
 	}
 	return nil
 }
@@ -200,14 +367,24 @@ func (f *Fury) WriteInt16(buffer *ByteBuffer, v interface{}) {
 
 func (f *Fury) WriteInt32(buffer *ByteBuffer, v interface{}) {
 	buffer.WriteInt8(NotNullValueFlag)
-	buffer.WriteInt16(INT32)
-	buffer.WriteInt32(v.(int32))
+	if f.config.intEncoding == INT_ENCODING_VARINT {
+		buffer.WriteInt16(FURY_VAR_INT32)
+		buffer.WriteSignedVarInt32(v.(int32))
+	} else {
+		buffer.WriteInt16(INT32)
+		buffer.WriteInt32(v.(int32))
+	}
 }
 
 func (f *Fury) WriteInt64(buffer *ByteBuffer, v interface{}) {
 	buffer.WriteInt8(NotNullValueFlag)
-	buffer.WriteInt16(INT64)
-	buffer.WriteInt64(v.(int64))
+	if f.config.intEncoding == INT_ENCODING_VARINT {
+		buffer.WriteInt16(FURY_VAR_INT64)
+		buffer.WriteSignedVarInt64(v.(int64))
+	} else {
+		buffer.WriteInt16(INT64)
+		buffer.WriteInt64(v.(int64))
+	}
 }
 
 func (f *Fury) WriteFloat32(buffer *ByteBuffer, v interface{}) {
@@ -230,8 +407,33 @@ func (f *Fury) writeLength(buffer *ByteBuffer, value int) error {
 	return nil
 }
 
+// readLength reads the number of elements of a collection, panics with *LimitError if it exceeds the max
+// collection size. The panic is recovered by `Deserialize`.
 func (f *Fury) readLength(buffer *ByteBuffer) int {
-	return int(buffer.ReadVarInt32())
+	length := buffer.ReadLength()
+	if max := f.config.maxCollectionSize; max > 0 && length > max {
+		panic(&LimitError{Offset: buffer.readerIndex, Limit: "collection size", Value: length, Max: max})
+	}
+	return length
+}
+
+// readBinaryLength reads the size of a string or binary in bytes, panics with *LimitError if it exceeds the
+// max binary size.
+func (f *Fury) readBinaryLength(buffer *ByteBuffer) int {
+	length := buffer.ReadLength()
+	if max := f.config.maxBinarySize; max > 0 && length > max {
+		panic(&LimitError{Offset: buffer.readerIndex, Limit: "binary size", Value: length, Max: max})
+	}
+	return length
+}
+
+// allocate records `size` bytes allocated for deserialized values, panics with *LimitError if the total bytes
+// exceed the max total bytes. It should be called before the memory is allocated.
+func (f *Fury) allocate(buffer *ByteBuffer, size int) {
+	f.allocatedBytes += size
+	if max := f.config.maxTotalBytes; max > 0 && f.allocatedBytes > max {
+		panic(&LimitError{Offset: buffer.readerIndex, Limit: "total bytes", Value: f.allocatedBytes, Max: max})
+	}
 }
 
 func (f *Fury) WriteReferencable(buffer *ByteBuffer, value reflect.Value) error {
@@ -253,6 +455,15 @@ func (f *Fury) writeReferencableBySerializer(buffer *ByteBuffer, value reflect.V
 	}
 }
 
+// writeNullableBySerializer writes a null flag for nil value, or the value without tracking its reference.
+func (f *Fury) writeNullableBySerializer(buffer *ByteBuffer, value reflect.Value, serializer Serializer) error {
+	if isNil(value) {
+		buffer.WriteInt8(NullFlag)
+		return nil
+	}
+	return f.writeNonReferencableBySerializer(buffer, value, serializer)
+}
+
 func (f *Fury) writeNonReferencableBySerializer(
 	buffer *ByteBuffer, value reflect.Value, serializer Serializer) error {
 	buffer.WriteInt8(NotNullValueFlag)
@@ -271,28 +482,47 @@ func (f *Fury) writeValue(buffer *ByteBuffer, value reflect.Value, serializer Se
 		}
 	}
 	typeId := serializer.TypeId()
+	if typeId == -FURY_TYPE_TAG && f.typeResolver.valueImplementations[type_] {
+		// values of implementations are tagged as pointers, so peers can read them without golang type info.
+		typeId = FURY_TYPE_TAG
+	}
 	buffer.WriteInt16(typeId)
-	if typeId != NotSupportCrossLanguage {
-		if typeId == FURY_TYPE_TAG {
-			var typeTag string
-			if value.Kind() == reflect.Ptr {
-				typeTag = serializer.(*ptrToStructSerializer).typeTag
-			} else {
-				typeTag = serializer.(*structSerializer).typeTag
-			}
-			if err := f.typeResolver.writeTypeTag(buffer, typeTag); err != nil {
+	if typeId == NotSupportCrossLanguage {
+		if f.language == XLANG && !f.nativeSection {
+			// write the index of the object in native objects, which will be written after xlang objects.
+			if err := f.writeLength(buffer, len(f.nativeObjects)); err != nil {
 				return err
 			}
+			f.nativeObjects = append(f.nativeObjects, value)
+			return nil
 		}
-		if typeId < NotSupportCrossLanguage {
-			if err := f.typeResolver.writeType(buffer, type_); err != nil {
-				return err
-			}
+		if err := f.typeResolver.writeType(buffer, type_); err != nil {
+			return err
 		}
 		return serializer.Write(f, buffer, value)
-	} else {
-		return fmt.Errorf("type %v not supported", type_)
 	}
+	if typeId == FURY_TYPE_TAG {
+		var typeTag string
+		if s, ok := serializer.(*enumSerializer); ok {
+			typeTag = s.typeTag
+		} else if value.Kind() == reflect.Ptr {
+			typeTag = serializer.(*ptrToStructSerializer).typeTag
+		} else {
+			typeTag = serializer.(*structSerializer).typeTag
+		}
+		if err := f.typeResolver.writeTypeTag(buffer, typeTag); err != nil {
+			return err
+		}
+	}
+	if typeId == EXTENSION {
+		buffer.WriteInt16(serializer.(*marshallerSerializer).extId)
+	}
+	if typeId < NotSupportCrossLanguage {
+		if err := f.typeResolver.writeType(buffer, type_); err != nil {
+			return err
+		}
+	}
+	return serializer.Write(f, buffer, value)
 }
 
 func (f *Fury) WriteBufferObject(buffer *ByteBuffer, bufferObject BufferObject) error {
@@ -318,8 +548,21 @@ func (f *Fury) Unmarshal(data []byte, v interface{}) error {
 	return f.Deserialize(&ByteBuffer{data: data}, v, nil)
 }
 
-func (f *Fury) Deserialize(buf *ByteBuffer, v interface{}, buffers []*ByteBuffer) error {
+// Deserialize reads a value from `buf` and stores it in the value pointed to by v. Truncated or corrupted data
+// is reported as an error matching ErrBufferUnderflow or ErrInvalidData, the state of `buf` is unspecified then.
+func (f *Fury) Deserialize(buf *ByteBuffer, v interface{}, buffers []*ByteBuffer) (err error) {
 	defer f.resetRead()
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Ptr || rv.IsNil() {
+		return fmt.Errorf("deserialize into non-pointer or nil value %T", v)
+	}
+	f.typeResolver.registerGlobalTypes()
+	defer func() {
+		if r := recover(); r != nil {
+			err = recoverReadError(r)
+		}
+	}()
+	start := buf.readerIndex
 	if f.language == XLANG {
 		magicNumber := buf.ReadInt16()
 		if magicNumber != MAGIC_NUMBER {
@@ -328,7 +571,7 @@ func (f *Fury) Deserialize(buf *ByteBuffer, v interface{}, buffers []*ByteBuffer
 					"Please check whether the serialization is based on the xlang protocol and the data didn't corrupt",
 				MAGIC_NUMBER)
 		}
-	} else {
+	} else if f.language != GO {
 		return fmt.Errorf("%d language is not supported", f.language)
 	}
 	var bitmap = buf.ReadByte_()
@@ -340,6 +583,10 @@ func (f *Fury) Deserialize(buf *ByteBuffer, v interface{}, buffers []*ByteBuffer
 		return fmt.Errorf("big endian is not supported for now, please ensure peer machine is little endian")
 	}
 	isCrossLanguage := bitmap&isCrossLanguageFlag == isCrossLanguageFlag
+	if isCrossLanguage != (f.language == XLANG) {
+		return fmt.Errorf("the data is serialized by xlang protocol: %t, but fury language is %d",
+			isCrossLanguage, f.language)
+	}
 	if isCrossLanguage {
 		f.peerLanguage = buf.ReadByte_()
 	} else {
@@ -359,17 +606,70 @@ func (f *Fury) Deserialize(buf *ByteBuffer, v interface{}, buffers []*ByteBuffer
 		}
 	}
 	if isCrossLanguage {
-		buf.ReadInt32() // nativeObjectsStartOffset
+		nativeObjectsStartOffset := buf.ReadInt32()
 		nativeObjectsSize := buf.ReadInt32()
-		if f.peerLanguage == GO {
-			if nativeObjectsSize > 0 {
-				return fmt.Errorf("native serialization for golang is not supported currently")
-			}
+		if nativeObjectsSize == 0 {
+			return f.ReadReferencable(buf, rv.Elem())
+		}
+		if f.peerLanguage != GO {
+			return fmt.Errorf("native objects of %d language are not supported", f.peerLanguage)
+		}
+		readerIndex := buf.readerIndex
+		if nativeObjectsSize < 0 || int(nativeObjectsStartOffset) < readerIndex-start ||
+			int(nativeObjectsStartOffset) > len(buf.data)-start {
+			return invalidDataError(buf, "invalid native objects offset %d and size %d",
+				nativeObjectsStartOffset, nativeObjectsSize)
+		}
+		buf.readerIndex = start + int(nativeObjectsStartOffset)
+		if err := f.readNativeObjects(buf, int(nativeObjectsSize)); err != nil {
+			return err
 		}
-		return f.ReadReferencable(buf, reflect.ValueOf(v).Elem())
+		nativeObjectsEnd := buf.readerIndex
+		buf.readerIndex = readerIndex
+		if err := f.ReadReferencable(buf, rv.Elem()); err != nil {
+			return err
+		}
+		buf.readerIndex = nativeObjectsEnd
+		return nil
 	} else {
-		return fmt.Errorf("native serialization for golang is not supported currently")
+		return f.ReadReferencable(buf, rv.Elem())
+	}
+}
+
+// readNativeObjects reads the native objects written by `writeNativeObjects`. Those objects are read into
+// interface values, so types which aren't builtin must be registered by `RegisterType` or `RegisterTagType`.
+func (f *Fury) readNativeObjects(buf *ByteBuffer, size int) error {
+	f.nativeSection = true
+	defer func() {
+		f.nativeSection = false
+	}()
+	for i := 0; i < size; i++ {
+		var object interface{}
+		if err := f.ReadReferencable(buf, reflect.ValueOf(&object).Elem()); err != nil {
+			return err
+		}
+		f.nativeReadObjects = append(f.nativeReadObjects, reflect.ValueOf(object))
+	}
+	// xlang objects have separate reference and meta string states.
+	f.typeResolver.resetRead()
+	f.refResolver.resetRead()
+	return nil
+}
+
+func (f *Fury) readNativeObject(buf *ByteBuffer, value reflect.Value) error {
+	index := int(buf.ReadVarInt32())
+	if index < 0 || index >= len(f.nativeReadObjects) {
+		return fmt.Errorf("native object index %d out of range [0, %d)", index, len(f.nativeReadObjects))
+	}
+	object := f.nativeReadObjects[index]
+	if !object.IsValid() {
+		return nil
+	}
+	if !object.Type().AssignableTo(value.Type()) {
+		return fmt.Errorf("native object of type %s can't be assigned to %s", object.Type(), value.Type())
 	}
+	value.Set(object)
+	return nil
 }
 
 func (f *Fury) ReadReferencable(buffer *ByteBuffer, value reflect.Value) error {
@@ -399,77 +699,195 @@ func (f *Fury) readReferencableBySerializer(buf *ByteBuffer, value reflect.Value
 		if refId == int32(NullFlag) {
 			return nil
 		}
-		value.Set(f.refResolver.GetCurrentReadObject())
+		// object may be invalid if it was skipped in compatible mode.
+		if object := f.refResolver.GetCurrentReadObject(); object.IsValid() {
+			if !object.Type().AssignableTo(value.Type()) {
+				return invalidDataError(buf, "reference of type %s can't be assigned to %s",
+					object.Type(), value.Type())
+			}
+			value.Set(object)
+		}
 		return nil
 	}
 }
 
+// readUntrackedData reads data whose reference isn't tracked, such as fields with `ref=false` option.
+func (f *Fury) readUntrackedData(buffer *ByteBuffer, value reflect.Value, serializer Serializer) error {
+	if !f.referenceTracking || !nullable(value.Type()) {
+		return f.readData(buffer, value, serializer)
+	}
+	// serializers of reference types invoke `refResolver.Reference` for the new object, preserve a stub ref id
+	// for it so the ref ids preserved for other objects aren't taken.
+	f.refResolver.preserveStubRefId()
+	defer f.refResolver.releaseStubRefId()
+	return f.readData(buffer, value, serializer)
+}
+
 func (f *Fury) readData(buffer *ByteBuffer, value reflect.Value, serializer Serializer) (err error) {
+	if f.depth++; f.depth > f.config.maxDepth {
+		return &LimitError{Offset: buffer.readerIndex, Limit: "depth", Value: f.depth, Max: f.config.maxDepth}
+	}
+	defer func() {
+		f.depth--
+	}()
 	typeId := buffer.ReadInt16()
-	if typeId != NotSupportCrossLanguage {
-		var type_ reflect.Type
-		if typeId == FURY_TYPE_TAG {
-			type_, err = f.typeResolver.readTypeByReadTag(buffer)
+	var type_ reflect.Type
+	if typeId == NotSupportCrossLanguage {
+		if f.language == XLANG && !f.nativeSection {
+			return f.readNativeObject(buffer, value)
+		}
+		type_, err = f.readType(buffer, value)
+		if err != nil {
+			return err
+		}
+	} else if typeId == FURY_TYPE_TAG {
+		type_, err = f.typeResolver.readTypeByReadTag(buffer, value)
+		if err != nil {
+			return f.skipUnknownStruct(buffer, true, err)
+		}
+		if type_.Kind() == reflect.Ptr && f.typeResolver.isValueImplementation(type_.Elem(), value) {
+			// read as a pointer, so the reference of the struct written by peers is recorded.
+			ptrSerializer, err := f.typeResolver.getSerializerByType(type_)
 			if err != nil {
 				return err
 			}
-		}
-		if typeId < NotSupportCrossLanguage {
-			if f.peerLanguage != GO {
-				// skip peer language specific type info
-				_, err = f.typeResolver.readTypeInfo(buffer)
-				if err != nil {
-					return err
-				}
-				type_, err = f.typeResolver.getTypeById(-typeId)
-				if err != nil {
-					return err
-				}
-			} else {
-				type_, err = f.typeResolver.readType(buffer)
-				if err != nil {
-					return err
-				}
-			}
-		} else {
-			if typeId != FURY_TYPE_TAG {
-				type_, err = f.typeResolver.getTypeById(typeId)
-				if err != nil {
-					return err
-				}
+			ptr := reflect.New(type_).Elem()
+			if err := ptrSerializer.Read(f, buffer, type_, ptr); err != nil {
+				return err
 			}
+			value.Set(ptr.Elem())
+			return nil
 		}
-		if serializer == nil {
-			serializer, err = f.typeResolver.getSerializerByType(type_)
+	} else if typeId == EXTENSION {
+		type_, err = f.typeResolver.readExtType(buffer, value)
+		if err != nil {
+			return err
+		}
+	} else if typeId < NotSupportCrossLanguage {
+		if f.peerLanguage != GO {
+			// skip peer language specific type info
+			_, err = f.typeResolver.readTypeInfo(buffer)
 			if err != nil {
 				return err
 			}
-		}
-		// `type_` may be more concrete than `value.Type()`. For example, `value.Type()` may be interface type.
-		// in serializers.
-		if value.Kind() == reflect.Interface {
-			// interfaceValue.Elem is not addressable, so we don't invoke `Elem` on interface. We create a new
-			// addressable concreate value to populate instead. Otherwise, we will need to handle interface in
-			// every serializers.
-			newValue := reflect.New(type_).Elem()
-			err := serializer.Read(f, buffer, type_, newValue)
+			type_, err = f.typeResolver.getTypeById(-typeId)
 			if err != nil {
 				return err
 			}
-			value.Set(newValue)
-			return nil
 		} else {
-			// handle value nil in the serializers since default value of most types are not nil
-			// and for nil, those values are composite values, check is cheap.
-			return serializer.Read(f, buffer, type_, value)
+			type_, err = f.readType(buffer, value)
+			if err != nil {
+				return f.skipUnknownStruct(buffer, false, err)
+			}
+			if isBigNumberType(type_) && isBigNumberType(value.Type()) {
+				// big numbers are all decimals in the data, which can be read into each other.
+				type_ = value.Type()
+			}
 		}
 	} else {
-		typeInfo, err := f.typeResolver.readTypeInfo(buffer)
+		type_, err = f.typeResolver.getTypeById(typeId)
+		if err != nil {
+			return err
+		}
+		if typeId == DECIMAL && isBigNumberType(value.Type()) {
+			// decimals of peers, such as java BigDecimal, can be read into big numbers.
+			type_ = value.Type()
+		}
+		if s, ok := unitSerializers[typeId]; ok {
+			if value.Kind() != reflect.Interface && value.Type() != type_ {
+				return invalidDataError(buffer, "value of type %s can't be read into %s", type_, value.Type())
+			}
+			serializer = s
+		}
+	}
+	if value.Kind() != reflect.Interface && !canReadInto(type_, value.Type()) {
+		return invalidDataError(buffer, "value of type %s can't be read into %s", type_, value.Type())
+	}
+	if s, ok := intReadSerializer(typeId, type_); ok {
+		serializer = s
+	} else if serializer == nil {
+		serializer, err = f.typeResolver.getSerializerByType(type_)
 		if err != nil {
 			return err
 		}
-		return fmt.Errorf("native objects of type %s not supported for now", typeInfo)
 	}
+	// `type_` may be more concrete than `value.Type()`. For example, `value.Type()` may be interface type.
+	// in serializers.
+	if value.Kind() == reflect.Interface {
+		if !type_.AssignableTo(value.Type()) {
+			return invalidDataError(buffer, "value of type %s can't be read into %s", type_, value.Type())
+		}
+		// interfaceValue.Elem is not addressable, so we don't invoke `Elem` on interface. We create a new
+		// addressable concreate value to populate instead. Otherwise, we will need to handle interface in
+		// every serializers.
+		if elems := arrayElems(type_); elems > len(buffer.data)-buffer.readerIndex {
+			// the size of a registered array type isn't bounded by the data when it's decoded.
+			return invalidDataError(buffer, "%s has more elements than the data holds", type_)
+		}
+		f.allocate(buffer, int(type_.Size()))
+		newValue := reflect.New(type_).Elem()
+		err := serializer.Read(f, buffer, type_, newValue)
+		if err != nil {
+			return err
+		}
+		value.Set(newValue)
+		return nil
+	} else {
+		// handle value nil in the serializers since default value of most types are not nil
+		// and for nil, those values are composite values, check is cheap.
+		return serializer.Read(f, buffer, type_, value)
+	}
+}
+
+// readType reads golang type info. Types which aren't registered can still be read into a value of
+// the same type, since the type info of those types are encoded from type names.
+func (f *Fury) readType(buffer *ByteBuffer, value reflect.Value) (reflect.Type, error) {
+	typeInfo, err := f.typeResolver.readTypeInfo(buffer)
+	if err != nil {
+		return nil, err
+	}
+	if value.Kind() != reflect.Interface {
+		// the type of a concrete value is chosen by the caller, so it's not checked by the type checker.
+		if type_, ok := f.typeResolver.typeInfoToType[typeInfo]; ok {
+			return type_, nil
+		}
+		if valueTypeInfo, err := f.typeResolver.encodeType(value.Type()); err == nil && valueTypeInfo == typeInfo {
+			return value.Type(), nil
+		}
+	}
+	// every element of an array takes a byte in the data at least.
+	maxArrayElems := len(buffer.data) - buffer.readerIndex
+	if max := f.config.maxCollectionSize; max > 0 && max < maxArrayElems {
+		maxArrayElems = max
+	}
+	type_, err := f.typeResolver.getTypeByTypeInfo(typeInfo, maxArrayElems)
+	var lengthErr *arrayLengthError
+	if errors.As(err, &lengthErr) {
+		return nil, invalidDataError(buffer, "%s", lengthErr)
+	}
+	return type_, err
+}
+
+// skipUnknownStruct skips the data of a struct whose tag isn't registered locally if compatible mode is enabled,
+// since its fields meta is carried in the data. Returns `err` otherwise.
+func (f *Fury) skipUnknownStruct(buffer *ByteBuffer, isPtr bool, err error) error {
+	if _, ok := err.(*unregisteredTagError); !ok || f.compatibleMode != COMPATIBLE {
+		return err
+	}
+	if isPtr {
+		// consume the ref id preserved for the pointer, as `ptrToStructSerializer` does.
+		f.refResolver.Reference(reflect.Value{})
+	}
+	typeDef, err := f.typeResolver.readTypeDef(buffer)
+	if err != nil {
+		return err
+	}
+	for _, field := range typeDef.fields {
+		if err := skipField(f, buffer, field); err != nil {
+			return err
+		}
+	}
+	return nil
 }
 
 func (f *Fury) ReadBufferObject(buffer *ByteBuffer) (*ByteBuffer, error) {
@@ -477,12 +895,10 @@ func (f *Fury) ReadBufferObject(buffer *ByteBuffer) (*ByteBuffer, error) {
 	// TODO(chaokunyang) We need a way to wrap out-of-band buffer into byte slice without copy.
 	// See more at `https://github.com/golang/go/wiki/cgo#turning-c-arrays-into-go-slices`
 	if isInBand {
-		size := buffer.ReadLength()
-		buf := buffer.Slice(buffer.readerIndex, size)
-		buffer.readerIndex += size
-		return buf, nil
+		size := f.readBinaryLength(buffer)
+		return NewByteBuffer(buffer.ReadBinary(size)), nil
 	} else {
-		if f.buffers == nil {
+		if len(f.buffers) == 0 {
 			return nil, fmt.Errorf("buffers shouldn't be nil when met a out-of-band buffer")
 		}
 		buf := f.buffers[0]
@@ -499,19 +915,37 @@ func (f *Fury) Reset() {
 func (f *Fury) resetWrite() {
 	f.typeResolver.resetWrite()
 	f.refResolver.resetWrite()
+	f.nativeObjects = nil
 }
 
 func (f *Fury) resetRead() {
 	f.typeResolver.resetRead()
 	f.refResolver.resetRead()
+	f.nativeReadObjects = nil
+	f.depth = 0
+	f.allocatedBytes = 0
+}
+
+// Config returns the config of this Fury, which can be used to create other Fury instances.
+func (f *Fury) Config() *Config {
+	return f.config
 }
 
 // methods for configure fury.
 
+// Deprecated: use NewFury(WithLanguage(language)) instead.
 func (f *Fury) SetLanguage(language Language) {
+	config := *f.config
+	config.language = language
+	f.config = &config
 	f.language = language
 }
 
+// Deprecated: use NewFury(WithRefTracking(referenceTracking)) instead.
 func (f *Fury) SetReferenceTracking(referenceTracking bool) {
+	config := *f.config
+	config.referenceTracking = referenceTracking
+	f.config = &config
 	f.referenceTracking = referenceTracking
+	f.refResolver = newRefResolver(referenceTracking)
 }
@@ -18,10 +18,12 @@
 package fury
 
 import (
+	"errors"
 	"fmt"
 	"github.com/stretchr/testify/require"
 	"reflect"
 	"testing"
+	"time"
 	"unsafe"
 )
 
@@ -77,7 +79,7 @@ func commonSlice() []interface{} {
 
 func commonMap() []interface{} {
 	return []interface{}{
-		map[string]bool{"k1": false, "k2": true, "str": true, "": true},
+		map[string]bool{"k1": true, "k2": true, "str": true, "": true},
 		map[string]byte{"k1": 1, "k2": 1, "str": 2, "": 3},
 		map[string]int8{"k1": 1, "k2": 1, "str": 2, "": 3},
 		map[string]int16{"k1": 1, "k2": 1, "str": 2, "": 3},
@@ -87,7 +89,7 @@ func commonMap() []interface{} {
 		map[string]float64{"k1": 1, "k2": 1, "str": 2, "": 3},
 		map[string]int32{"k1": 1, "k2": -1, "str": 2, "": 3},
 		map[string]string{"k1": "v1", "k2": "v2", "str": "", "": ""},
-		map[bool]bool{true: false, false: true},
+		map[bool]bool{true: true, false: true},
 		map[byte]byte{1: 1, 2: 2, 3: 3},
 		map[int8]int8{1: 1, 2: 2, 3: 3},
 		map[int16]int16{1: 1, 2: 2, 3: 3},
@@ -117,7 +119,7 @@ func commonArray() []interface{} {
 
 func TestSerializePrimitives(t *testing.T) {
 	for _, referenceTracking := range []bool{false, true} {
-		fury := NewFury(referenceTracking)
+		fury := NewFury(WithRefTracking(referenceTracking))
 		for _, value := range primitiveData() {
 			serde(t, fury, value)
 		}
@@ -126,7 +128,7 @@ func TestSerializePrimitives(t *testing.T) {
 
 func TestSerializeInterface(t *testing.T) {
 	for _, referenceTracking := range []bool{false, true} {
-		fury := NewFury(referenceTracking)
+		fury := NewFury(WithRefTracking(referenceTracking))
 		var a interface{}
 		a = -1
 		serde(t, fury, a)
@@ -143,7 +145,7 @@ func TestSerializeInterface(t *testing.T) {
 
 func TestSerializePtr(t *testing.T) {
 	for _, referenceTracking := range []bool{false, true} {
-		fury := NewFury(referenceTracking)
+		fury := NewFury(WithRefTracking(referenceTracking))
 		a := -100
 		b := &a
 		serde(t, fury, b)
@@ -159,7 +161,7 @@ func TestSerializePtr(t *testing.T) {
 
 func TestSerializeSlice(t *testing.T) {
 	for _, referenceTracking := range []bool{false, true} {
-		fury := NewFury(referenceTracking)
+		fury := NewFury(WithRefTracking(referenceTracking))
 		serde(t, fury, []byte{0, 1, MaxUint8})
 		serde(t, fury, []int8{MinInt8, -1, 0, 1, MaxInt8})
 		serde(t, fury, []int16{MinInt16, -1, 0, 1, MaxInt16})
@@ -179,7 +181,7 @@ func TestSerializeSlice(t *testing.T) {
 
 func TestSerializeMap(t *testing.T) {
 	for _, referenceTracking := range []bool{false, true} {
-		fury := NewFury(referenceTracking)
+		fury := NewFury(WithRefTracking(referenceTracking))
 		// "str1" is deserialized by interface type, which will be set to map key whose type is string.
 		// so we need to save interface dynamic value type instead of interface value in reference resolver.
 		{
@@ -207,7 +209,7 @@ func TestSerializeMap(t *testing.T) {
 
 func TestSerializeArray(t *testing.T) {
 	for _, referenceTracking := range []bool{false, true} {
-		fury := NewFury(referenceTracking)
+		fury := NewFury(WithRefTracking(referenceTracking))
 		for _, data := range commonArray() {
 			serde(t, fury, data)
 		}
@@ -217,7 +219,7 @@ func TestSerializeArray(t *testing.T) {
 
 func TestSerializeStructSimple(t *testing.T) {
 	for _, referenceTracking := range []bool{false, true} {
-		fury := NewFury(referenceTracking)
+		fury := NewFury(WithRefTracking(referenceTracking))
 		type A struct {
 			F1 []string
 		}
@@ -245,7 +247,7 @@ func TestSerializeStructSimple(t *testing.T) {
 
 func TestSerializeBeginWithMagicNumber(t *testing.T) {
 	strSlice := []string{"str1", "str1", "", "", "str2"}
-	fury := NewFury(true)
+	fury := NewFury(WithRefTracking(true))
 	bytes, err := fury.Marshal(strSlice)
 	require.Nil(t, err, fmt.Sprintf("serialize value %s with type %s failed: %s",
 		reflect.ValueOf(strSlice), reflect.TypeOf(strSlice), err))
@@ -290,7 +292,7 @@ func newFoo() Foo {
 
 func TestSerializeStruct(t *testing.T) {
 	for _, referenceTracking := range []bool{false, true} {
-		fury := NewFury(referenceTracking)
+		fury := NewFury(WithRefTracking(referenceTracking))
 		require.Nil(t, fury.RegisterTagType("example.Bar", Bar{}))
 		serde(t, fury, &Bar{})
 		bar := Bar{F1: 1, F2: "str"}
@@ -314,8 +316,1155 @@ func TestSerializeStruct(t *testing.T) {
 	}
 }
 
+func TestSerializeStructCompatible(t *testing.T) {
+	for _, referenceTracking := range []bool{false, true} {
+		fury := NewFury(WithRefTracking(referenceTracking), WithCompatibleMode(COMPATIBLE))
+		require.Nil(t, fury.RegisterTagType("example.Bar", Bar{}))
+		require.Nil(t, fury.RegisterTagType("example.Foo", Foo{}))
+		foo := newFoo()
+		serde(t, fury, foo)
+		serde(t, fury, &foo)
+		serde(t, fury, []interface{}{&foo, foo, &Bar{F1: 1}, Bar{F2: "str"}})
+
+		type Meta struct {
+			F1 string
+		}
+		type V1 struct {
+			F1 int32
+			F2 string
+			F3 []string
+			F4 *Meta
+			F5 map[string]int32
+			F6 []*Meta
+		}
+		// F1 is removed, F3 changes type, F2/F6 are reordered and F7 is added.
+		type V2 struct {
+			F6 []*Meta
+			F2 string
+			F3 []int64
+			F7 int64
+		}
+		writer := NewFury(WithRefTracking(referenceTracking), WithCompatibleMode(COMPATIBLE))
+		require.Nil(t, writer.RegisterTagType("example.V", V1{}))
+		require.Nil(t, writer.RegisterTagType("example.Meta", Meta{}))
+		reader := NewFury(WithRefTracking(referenceTracking), WithCompatibleMode(COMPATIBLE))
+		require.Nil(t, reader.RegisterTagType("example.V", V2{}))
+		require.Nil(t, reader.RegisterTagType("example.Meta", Meta{}))
+
+		meta := &Meta{F1: "meta"}
+		v1 := &V1{
+			F1: 1,
+			F2: "str",
+			F3: []string{"a", "b"},
+			F4: meta,
+			F5: map[string]int32{"k1": 1},
+			F6: []*Meta{meta, meta},
+		}
+		bytes, err := writer.Marshal([]*V1{v1, v1})
+		require.Nil(t, err)
+		var v2 []*V2
+		require.Nil(t, reader.Unmarshal(bytes, &v2))
+		require.Equal(t, 2, len(v2))
+		require.Equal(t, &V2{F6: []*Meta{meta, meta}, F2: "str"}, v2[0])
+		require.Equal(t, v2[0], v2[1])
+		if referenceTracking {
+			require.Same(t, v2[0], v2[1])
+			require.Same(t, v2[0].F6[0], v2[0].F6[1])
+		}
+
+		// struct values whose types aren't registered in reader are skipped too.
+		type W struct {
+			F1 *Meta
+			F2 string
+			F3 Meta
+			F4 interface{}
+		}
+		type R struct {
+			F2 string
+		}
+		unknownWriter := NewFury(WithRefTracking(referenceTracking), WithCompatibleMode(COMPATIBLE))
+		require.Nil(t, unknownWriter.RegisterTagType("example.W", W{}))
+		require.Nil(t, unknownWriter.RegisterTagType("example.Meta", Meta{}))
+		unknownReader := NewFury(WithRefTracking(referenceTracking), WithCompatibleMode(COMPATIBLE))
+		require.Nil(t, unknownReader.RegisterTagType("example.W", R{}))
+		bytes, err = unknownWriter.Marshal([]interface{}{&W{F1: meta, F2: "str", F3: *meta, F4: meta}, meta})
+		require.Nil(t, err)
+		var values []interface{}
+		require.Nil(t, unknownReader.Unmarshal(bytes, &values))
+		require.Equal(t, 2, len(values))
+		require.Equal(t, &R{F2: "str"}, values[0])
+		require.Nil(t, values[1])
+
+		// values of named types which aren't known by reader are skipped by the type ids in the data.
+		type Status2 int32
+		type S struct {
+			F1 Status2
+			F2 string
+			F3 []Status2
+			F4 map[Status2]*Status2
+			F5 interface{}
+		}
+		statusWriter := NewFury(WithRefTracking(referenceTracking), WithCompatibleMode(COMPATIBLE))
+		require.Nil(t, statusWriter.RegisterTagType("example.W", S{}))
+		status := Status2(3)
+		bytes, err = statusWriter.Marshal(&S{F1: 1, F2: "str", F3: []Status2{1, 2}, F4: map[Status2]*Status2{1: &status},
+			F5: []interface{}{status, MyInt(1)}})
+		require.Nil(t, err)
+		var r *R
+		require.Nil(t, unknownReader.Unmarshal(bytes, &r))
+		require.Equal(t, &R{F2: "str"}, r)
+
+		// schema consistent mode still reports inconsistent struct.
+		consistentReader := NewFury(WithRefTracking(referenceTracking))
+		require.Nil(t, consistentReader.RegisterTagType("example.V", V2{}))
+		consistentWriter := NewFury(WithRefTracking(referenceTracking))
+		require.Nil(t, consistentWriter.RegisterTagType("example.V", V1{}))
+		require.Nil(t, consistentWriter.RegisterTagType("example.Meta", Meta{}))
+		bytes, err = consistentWriter.Marshal(v1)
+		require.Nil(t, err)
+		var v3 *V2
+		require.NotNil(t, consistentReader.Unmarshal(bytes, &v3))
+	}
+}
+
+type MyInt int32
+type MyString string
+type MyIds []int64
+type Point struct {
+	X, Y uint32
+	Tags []MyString
+}
+type Account struct {
+	Name    string
+	balance uint64
+	owner   *Account
+}
+
+func goData() []interface{} {
+	return []interface{}{
+		uint16(MaxUint16),
+		uint32(MaxUint32),
+		uint64(MaxUint64),
+		uint(1),
+		uintptr(1),
+		complex64(complex(1, -1)),
+		complex(1.5, -2.5),
+		MyInt(-1),
+		MyString("str"),
+		MyIds{1, 2, 3},
+		Point{X: 1, Y: 2, Tags: []MyString{"a", "b"}},
+		&Point{X: 3},
+		[]uint64{0, 1, MaxUint64},
+		map[string]uint32{"k1": 1},
+		[2]complex128{1, 2},
+	}
+}
+
+type Counter struct {
+	Id    uint64
+	Count uint32
+	Mask  uint16
+	Flag  uint8
+	Total uint
+	Ids   []uint32
+}
+
+func TestSerializeUnsigned(t *testing.T) {
+	for _, referenceTracking := range []bool{false, true} {
+		fury := NewFury(WithRefTracking(referenceTracking))
+		require.Nil(t, fury.RegisterTagType("example.Counter", Counter{}))
+		for _, value := range []interface{}{
+			uint16(MaxUint16), uint32(MaxUint32), uint64(MaxUint64), uint(MaxUint32), uintptr(1),
+			[]uint16{0, MaxUint16}, []uint32{0, MaxUint32}, []uint64{0, MaxUint64}, []uint{0, MaxUint32},
+			map[string]uint32{"k1": 1}, []interface{}{uint16(1), uint32(2), uint64(3)},
+			Counter{Id: MaxUint64, Count: MaxUint32, Mask: MaxUint16, Flag: MaxUint8, Total: 1, Ids: []uint32{1}},
+		} {
+			serde(t, fury, value)
+		}
+		// unsigned integers are written as xlang types without native objects.
+		bytes, err := fury.Marshal(uint32(MaxUint32))
+		require.Nil(t, err)
+		buffer := NewByteBuffer(bytes)
+		buffer.SetReaderIndex(4)
+		require.Equal(t, int32(0), buffer.ReadInt32())
+		require.Equal(t, int32(0), buffer.ReadInt32())
+		require.Equal(t, NotNullValueFlag, buffer.ReadInt8())
+		require.Equal(t, UINT32, buffer.ReadInt16())
+		require.Equal(t, uint32(MaxUint32), buffer.ReadUint32())
+
+		// unsigned slices are written as signed primitive arrays of the same size with golang type info.
+		bytes, err = fury.Marshal([]uint32{1})
+		require.Nil(t, err)
+		buffer = NewByteBuffer(bytes)
+		buffer.SetReaderIndex(12)
+		if referenceTracking {
+			require.Equal(t, RefValueFlag, buffer.ReadInt8())
+		} else {
+			require.Equal(t, NotNullValueFlag, buffer.ReadInt8())
+		}
+		require.Equal(t, int16(-FURY_PRIMITIVE_INT_ARRAY), buffer.ReadInt16())
+	}
+}
+
+type Metrics struct {
+	Count   int32 `fury:",encoding=varint"`
+	Total   int64 `fury:",encoding=varint"`
+	Id      int64 `fury:",encoding=fixed"`
+	Size    MyInt `fury:",encoding=varint"`
+	Samples []int64
+	Labels  map[string]int32
+}
+
+func TestSerializeVarInt(t *testing.T) {
+	for _, referenceTracking := range []bool{false, true} {
+		fixedFury := NewFury(WithRefTracking(referenceTracking), WithLanguage(GO))
+		fury := NewFury(WithRefTracking(referenceTracking), WithLanguage(GO), WithIntEncoding(INT_ENCODING_VARINT))
+		require.Nil(t, fury.RegisterTagType("example.Metrics", Metrics{}))
+		require.Nil(t, fixedFury.RegisterTagType("example.Metrics", Metrics{}))
+		for _, value := range []interface{}{
+			int32(0), int32(MinInt32), int64(MaxInt64), int64(MinInt64), 1, -1,
+			[]interface{}{int32(-1), int64(1)}, map[string]int64{"k1": MinInt64}, []int64{1, MaxInt64},
+			Metrics{Count: -1, Total: MaxInt64, Id: 1, Size: 2, Samples: []int64{1}, Labels: map[string]int32{"k": 1}},
+		} {
+			serde(t, fury, value)
+			serde(t, fixedFury, value)
+		}
+		fixedBytes, err := fixedFury.Marshal([]interface{}{int32(1), int64(1)})
+		require.Nil(t, err)
+		bytes, err := fury.Marshal([]interface{}{int32(1), int64(1)})
+		require.Nil(t, err)
+		require.Equal(t, len(fixedBytes)-3-7, len(bytes))
+		// varint values are written with FURY_VAR_INT32/FURY_VAR_INT64, so they can be read by golang peers of any
+		// int encoding.
+		for _, value := range []interface{}{int32(-1), int64(MaxInt64), 1, []interface{}{int32(1), int64(1), 1}} {
+			for _, furies := range [][2]*Fury{{fury, fixedFury}, {fixedFury, fury}} {
+				bytes, err := furies[0].Marshal(value)
+				require.Nil(t, err)
+				var newValue interface{}
+				require.Nil(t, furies[1].Unmarshal(bytes, &newValue))
+				require.Equal(t, value, newValue)
+				typed := reflect.New(reflect.TypeOf(value))
+				require.Nil(t, furies[1].Unmarshal(bytes, typed.Interface()))
+				require.Equal(t, value, typed.Elem().Interface())
+			}
+		}
+		// fields with encoding option are written the same whatever the config is.
+		fixedBytes, err = fixedFury.Marshal(Metrics{Count: 1, Total: 1, Id: 1})
+		require.Nil(t, err)
+		bytes, err = fury.Marshal(Metrics{Count: 1, Total: 1, Id: 1})
+		require.Nil(t, err)
+		require.Equal(t, fixedBytes, bytes)
+	}
+	// varint type ids are golang specific.
+	_, err := NewConfig(WithIntEncoding(INT_ENCODING_VARINT))
+	require.Error(t, err)
+	type InvalidTag struct {
+		Name string `fury:",encoding=varint"`
+	}
+	fury := NewFury()
+	require.Nil(t, fury.RegisterTagType("example.InvalidTag", InvalidTag{}))
+	_, err = fury.Marshal(InvalidTag{})
+	require.Error(t, err)
+}
+
+func TestSerializeStringEncoding(t *testing.T) {
+	for _, referenceTracking := range []bool{false, true} {
+		utf8Fury := NewFury(WithRefTracking(referenceTracking))
+		fury := NewFury(WithRefTracking(referenceTracking), WithStringEncoding(STRING_ENCODING_UTF16))
+		for _, f := range []*Fury{utf8Fury, fury} {
+			require.Nil(t, f.RegisterTagType("example.Bar", Bar{}))
+		}
+		str := "中文字符串"
+		for _, value := range []interface{}{
+			"", "str", str, "😀", []string{"a", str}, map[string]string{str: "b"}, &Bar{F1: 1, F2: str},
+		} {
+			serde(t, fury, value)
+		}
+		utf8Bytes, err := utf8Fury.Marshal(str)
+		require.Nil(t, err)
+		bytes, err := fury.Marshal(str)
+		require.Nil(t, err)
+		require.Equal(t, len(utf8Bytes)-5, len(bytes))
+		// invalid utf8 bytes are replaced.
+		bytes, err = fury.Marshal("a\xffb")
+		require.Nil(t, err)
+		var newStr string
+		require.Nil(t, fury.Unmarshal(bytes, &newStr))
+		require.Equal(t, "a\uFFFDb", newStr)
+		buf := NewByteBuffer(nil)
+		buf.WriteVarInt32(3)
+		buf.WriteBinary([]byte{'a', 0, 'b'})
+		require.Panics(t, func() { readString(fury, buf) })
+	}
+}
+
+func TestSerializeGoLanguage(t *testing.T) {
+	for _, referenceTracking := range []bool{false, true} {
+		fury := NewFury(WithRefTracking(referenceTracking), WithLanguage(GO))
+		for _, value := range []interface{}{MyInt(0), MyString(""), MyIds{}, Point{}} {
+			require.Nil(t, fury.RegisterType(value))
+		}
+		for _, value := range primitiveData() {
+			serde(t, fury, value)
+		}
+		for _, value := range goData() {
+			serde(t, fury, value)
+		}
+		serde(t, fury, goData())
+		require.Nil(t, fury.RegisterTagType("example.Bar", Bar{}))
+		require.Nil(t, fury.RegisterTagType("example.Foo", Foo{}))
+		serde(t, fury, newFoo())
+
+		// GO language data has no xlang header.
+		bytes, err := fury.Marshal(uint32(1))
+		require.Nil(t, err)
+		require.NotEqual(t, MAGIC_NUMBER, int16(bytes[0])|(int16(bytes[1])<<8))
+		require.Error(t, NewFury().Unmarshal(bytes, new(interface{})))
+
+		// unregistered types can be read into values of the same type.
+		bytes, err = NewFury(WithLanguage(GO)).Marshal(Point{X: 1, Tags: []MyString{"a"}})
+		require.Nil(t, err)
+		var point Point
+		require.Nil(t, NewFury(WithLanguage(GO)).Unmarshal(bytes, &point))
+		require.Equal(t, Point{X: 1, Tags: []MyString{"a"}}, point)
+		require.Error(t, NewFury(WithLanguage(GO)).Unmarshal(bytes, new(interface{})))
+	}
+}
+
+func TestSerializeNativeObjects(t *testing.T) {
+	for _, referenceTracking := range []bool{false, true} {
+		fury := NewFury(WithRefTracking(referenceTracking))
+		for _, value := range []interface{}{MyInt(0), MyString(""), MyIds{}, Point{}} {
+			require.Nil(t, fury.RegisterType(value))
+		}
+		for _, value := range goData() {
+			serde(t, fury, value)
+		}
+		serde(t, fury, goData())
+		value := []interface{}{"str", complex64(1), &Point{X: 1}, "str", &Point{X: 1}}
+		bytes, err := fury.Marshal(value)
+		require.Nil(t, err)
+		// the native objects section is recorded in header.
+		nativeObjectsBuffer := NewByteBuffer(bytes[4:])
+		require.Less(t, int(nativeObjectsBuffer.ReadInt32()), len(bytes))
+		require.Equal(t, int32(3), nativeObjectsBuffer.ReadInt32())
+		var newValue []interface{}
+		require.Nil(t, fury.Unmarshal(bytes, &newValue))
+		require.Equal(t, value, newValue)
+
+		// native objects don't break the framing of messages in the same buffer.
+		buf := NewByteBuffer(nil)
+		require.Nil(t, fury.Serialize(buf, value, nil))
+		require.Nil(t, fury.Serialize(buf, "end", nil))
+		require.Nil(t, fury.Deserialize(buf, &newValue, nil))
+		require.Equal(t, value, newValue)
+		var end string
+		require.Nil(t, fury.Deserialize(buf, &end, nil))
+		require.Equal(t, "end", end)
+	}
+}
+
+func TestSerializeUnexportedFields(t *testing.T) {
+	_, err := NewConfig(WithUnexportedFields(true))
+	require.Error(t, err)
+	fury := NewFury(WithRefTracking(true), WithLanguage(GO), WithUnexportedFields(true))
+	require.Nil(t, fury.RegisterType(Account{}))
+	account := &Account{Name: "a", balance: 100}
+	account.owner = account
+	bytes, err := fury.Marshal(account)
+	require.Nil(t, err)
+	var newAccount *Account
+	require.Nil(t, fury.Unmarshal(bytes, &newAccount))
+	require.Equal(t, "a", newAccount.Name)
+	require.Equal(t, uint64(100), newAccount.balance)
+	require.Same(t, newAccount, newAccount.owner)
+	// struct value isn't addressable.
+	serde(t, fury, Account{Name: "b", balance: 1})
+
+	fury = NewFury(WithLanguage(GO))
+	bytes, err = fury.Marshal(Account{Name: "b", balance: 1})
+	require.Nil(t, err)
+	var value Account
+	require.Nil(t, fury.Unmarshal(bytes, &value))
+	require.Equal(t, Account{Name: "b"}, value)
+}
+
+type MessageHeader struct {
+	Id    int64
+	Trace string
+}
+
+type auditInfo struct {
+	Creator string
+	Trace   string
+}
+
+// Message embeds headers whose fields are flattened, Trace of Message hides the fields of the same name.
+type Message struct {
+	MessageHeader
+	auditInfo
+	Body  string
+	Trace string
+}
+
+// FlatMessage is Message defined by a peer without embedded structs, such as a java subclass.
+type FlatMessage struct {
+	Id      int64
+	Creator string
+	Body    string
+	Trace   string
+}
+
+func TestEmbeddedStruct(t *testing.T) {
+	type X struct{ A, B int32 }
+	type Y struct{ A, C int32 }
+	type Ambiguous struct {
+		X
+		Y
+	}
+	type NotFlattened struct {
+		*MessageHeader
+		Y       `fury:"y"`
+		X       `fury:"-"`
+		Instant time.Time
+	}
+	fury := NewFury()
+	for type_, expected := range map[reflect.Type][]string{
+		reflect.TypeOf(Message{}):      {"body", "creator", "id", "trace"},
+		reflect.TypeOf(Ambiguous{}):    {"b", "c"},
+		reflect.TypeOf(NotFlattened{}): {"instant", "message_header", "y"},
+	} {
+		fields, err := createStructFieldInfos(fury, type_)
+		require.Nil(t, err)
+		var names []string
+		for _, field := range fields {
+			names = append(names, field.name)
+		}
+		require.Equal(t, expected, names)
+	}
+
+	message := Message{MessageHeader: MessageHeader{Id: 1, Trace: "hidden"}, auditInfo: auditInfo{Creator: "c"},
+		Body: "body", Trace: "trace"}
+	flat := FlatMessage{Id: 1, Creator: "c", Body: "body", Trace: "trace"}
+	for _, mode := range []CompatibleMode{SCHEMA_CONSISTENT, COMPATIBLE} {
+		fury := NewFury(WithCompatibleMode(mode))
+		require.Nil(t, fury.RegisterTagType("example.Message", Message{}))
+		peer := NewFury(WithCompatibleMode(mode))
+		require.Nil(t, peer.RegisterTagType("example.Message", FlatMessage{}))
+		bytes, err := fury.Marshal(message)
+		require.Nil(t, err)
+		peerBytes, err := peer.Marshal(flat)
+		require.Nil(t, err)
+		require.Equal(t, peerBytes, bytes)
+		var newFlat FlatMessage
+		require.Nil(t, peer.Unmarshal(bytes, &newFlat))
+		require.Equal(t, flat, newFlat)
+		var newMessage Message
+		require.Nil(t, fury.Unmarshal(peerBytes, &newMessage))
+		message.MessageHeader.Trace = ""
+		require.Equal(t, message, newMessage)
+	}
+	fury = NewFury(WithLanguage(GO))
+	require.Nil(t, fury.RegisterType(NotFlattened{}))
+	serde(t, fury, NotFlattened{MessageHeader: &MessageHeader{Id: 1}, Y: Y{A: 1, C: 2}, Instant: time.Unix(1, 0)})
+}
+
+type Shape interface {
+	Area() float64
+}
+
+type Circle struct {
+	Radius float64
+}
+
+func (c Circle) Area() float64 {
+	return 3 * c.Radius * c.Radius
+}
+
+type Square struct {
+	Side float64
+}
+
+func (s *Square) Area() float64 {
+	return s.Side * s.Side
+}
+
+type Envelope struct {
+	Main   Shape
+	Shapes []Shape
+}
+
+func TestSerializeImplementations(t *testing.T) {
+	newFury := func(implementations bool) *Fury {
+		fury := NewFury(WithRefTracking(true))
+		require.Nil(t, fury.RegisterTagType("example.Circle", Circle{}))
+		require.Nil(t, fury.RegisterTagType("example.Square", Square{}))
+		require.Nil(t, fury.RegisterTagType("example.Envelope", Envelope{}))
+		if implementations {
+			require.Nil(t, fury.RegisterImplementations((*Shape)(nil), Circle{}, &Square{}))
+		}
+		return fury
+	}
+	fury, peer := newFury(true), newFury(false)
+	square := &Square{Side: 2}
+	envelope := Envelope{Main: Circle{Radius: 1}, Shapes: []Shape{Circle{Radius: 2}, square, square, nil}}
+	serde(t, fury, envelope)
+	bytes, err := fury.Marshal(envelope)
+	require.Nil(t, err)
+	// structs are tagged as pointers by peers, which are read as the registered implementations.
+	peerEnvelope := Envelope{Main: &Circle{Radius: 1}, Shapes: []Shape{&Circle{Radius: 2}, square, square, nil}}
+	peerBytes, err := peer.Marshal(peerEnvelope)
+	require.Nil(t, err)
+	var newEnvelope Envelope
+	require.Nil(t, fury.Unmarshal(peerBytes, &newEnvelope))
+	require.Equal(t, envelope, newEnvelope)
+	require.Same(t, newEnvelope.Shapes[1], newEnvelope.Shapes[2])
+	newEnvelope = Envelope{}
+	require.Nil(t, peer.Unmarshal(bytes, &newEnvelope))
+	require.Equal(t, peerEnvelope, newEnvelope)
+
+	// tagged structs can be read into struct values.
+	peerBytes, err = peer.Marshal(&Circle{Radius: 3})
+	require.Nil(t, err)
+	for _, fury := range []*Fury{fury, peer} {
+		var circle Circle
+		require.Nil(t, fury.Unmarshal(peerBytes, &circle))
+		require.Equal(t, Circle{Radius: 3}, circle)
+	}
+	peerBytes, err = peer.Marshal(&Square{Side: 3})
+	require.Nil(t, err)
+	var shape Shape
+	require.Nil(t, fury.Unmarshal(peerBytes, &shape))
+	require.Equal(t, &Square{Side: 3}, shape)
+	var circle Circle
+	require.Error(t, fury.Unmarshal(peerBytes, &circle))
+	var stringer fmt.Stringer
+	require.Error(t, fury.Unmarshal(peerBytes, &stringer))
+
+	require.Error(t, fury.RegisterImplementations((*Shape)(nil), Point{}))
+	require.Error(t, fury.RegisterImplementations((*Shape)(nil), Square{}))
+	require.Error(t, fury.RegisterImplementations((*Shape)(nil), &Circle{}))
+	require.Error(t, fury.RegisterImplementations(Circle{}, Circle{}))
+	require.Error(t, fury.RegisterImplementations((*Shape)(nil), nil))
+}
+
+type TaggedUser struct {
+	UserId   int64       `fury:"userId"`
+	Name     string      `fury:"name,nullable=false"`
+	Password string      `fury:"-"`
+	Manager  *TaggedUser `fury:",ref=false"`
+	Friend   *TaggedUser
+	Roles    []string `fury:",nullable=false,ref=false"`
+	Level    int32    `fury:"level,nullable=false,encoding=varint"`
+}
+
+// PeerUser is TaggedUser defined by a peer with different golang field names.
+type PeerUser struct {
+	Id       int64  `fury:"userId"`
+	FullName string `fury:"name"`
+	Roles    []string
+}
+
+func TestStructTags(t *testing.T) {
+	fury := NewFury()
+	fields, err := createStructFieldInfos(fury, reflect.TypeOf(TaggedUser{}))
+	require.Nil(t, err)
+	var names []string
+	for _, field := range fields {
+		names = append(names, field.name)
+	}
+	require.Equal(t, []string{"friend", "level", "manager", "name", "roles", "userId"}, names)
+	require.Equal(t, []bool{true, false, true, false, false, false}, []bool{fields[0].referencable,
+		fields[1].nullable, fields[2].nullable, fields[3].nullable, fields[4].nullable, fields[5].nullable})
+	require.False(t, fields[2].referencable)
+
+	// renamed fields change the fields order and struct hash.
+	type A struct {
+		X int32
+		Y string
+	}
+	type B struct {
+		X int32 `fury:"z"`
+		Y string
+	}
+	require.Nil(t, fury.RegisterTagType("example.A", A{}))
+	require.Nil(t, fury.RegisterTagType("example.B", B{}))
+	hashes := map[int32]bool{}
+	for _, value := range []interface{}{A{}, B{}} {
+		serializer, err := fury.typeResolver.getSerializerByType(reflect.TypeOf(value))
+		require.Nil(t, err)
+		require.Nil(t, serializer.(*structSerializer).init(fury))
+		hashes[serializer.(*structSerializer).structHash] = true
+	}
+	require.Equal(t, 2, len(hashes))
+
+	boss := &TaggedUser{UserId: 1, Name: "boss", Roles: []string{"admin"}}
+	user := &TaggedUser{UserId: 2, Name: "user", Password: "secret", Manager: boss, Friend: boss, Level: -1}
+	for _, referenceTracking := range []bool{false, true} {
+		for _, mode := range []CompatibleMode{SCHEMA_CONSISTENT, COMPATIBLE} {
+			fury := NewFury(WithRefTracking(referenceTracking), WithCompatibleMode(mode))
+			require.Nil(t, fury.RegisterTagType("example.User", TaggedUser{}))
+			bytes, err := fury.Marshal(user)
+			require.Nil(t, err)
+			var newUser *TaggedUser
+			require.Nil(t, fury.Unmarshal(bytes, &newUser))
+			// skipped field isn't written, and nil slice which isn't nullable is read as empty slice.
+			require.Equal(t, "", newUser.Password)
+			require.Equal(t, []string{}, newUser.Roles)
+			newUser.Password, newUser.Roles = user.Password, nil
+			require.Equal(t, user, newUser)
+			if referenceTracking {
+				// reference of manager isn't tracked.
+				require.NotSame(t, newUser.Friend, newUser.Manager)
+			}
+			if mode == COMPATIBLE {
+				// fields of a struct which isn't registered are skipped by their tags.
+				unknownBytes, err := fury.Marshal([]interface{}{user, "str"})
+				require.Nil(t, err)
+				var values []interface{}
+				require.Nil(t, NewFury(WithRefTracking(referenceTracking), WithCompatibleMode(mode)).Unmarshal(
+					unknownBytes, &values))
+				require.Equal(t, []interface{}{nil, "str"}, values)
+				peerFury := NewFury(WithRefTracking(referenceTracking), WithCompatibleMode(mode))
+				require.Nil(t, peerFury.RegisterTagType("example.User", PeerUser{}))
+				var peerUser *PeerUser
+				require.Nil(t, peerFury.Unmarshal(bytes, &peerUser))
+				require.Equal(t, &PeerUser{Id: 2, FullName: "user", Roles: []string{}}, peerUser)
+				bytes, err = peerFury.Marshal(&PeerUser{Id: 3, FullName: "peer"})
+				require.Nil(t, err)
+				require.Nil(t, fury.Unmarshal(bytes, &newUser))
+				require.Equal(t, &TaggedUser{UserId: 3, Name: "peer"}, newUser)
+			}
+		}
+	}
+
+	type Required struct {
+		Next *Required `fury:",nullable=false"`
+	}
+	require.Nil(t, fury.RegisterTagType("example.Required", Required{}))
+	_, err = fury.Marshal(Required{})
+	require.Error(t, err)
+	for _, value := range []interface{}{
+		struct {
+			X int32 `fury:",nullable=true"`
+		}{},
+		struct {
+			X int32 `fury:",unknown"`
+		}{},
+		struct {
+			X int32
+			Y int32 `fury:"x"`
+		}{},
+	} {
+		_, err := createStructFieldInfos(fury, reflect.TypeOf(value))
+		require.Error(t, err)
+	}
+}
+
+type Version struct {
+	Major, Minor int32
+	Label        string
+}
+
+// versionSerializer is written as furygen generates.
+type versionSerializer struct {
+	fields []string
+}
+
+func (s versionSerializer) Fields() []string {
+	return s.fields
+}
+
+func (s versionSerializer) WriteFields(f *Fury, buf *ByteBuffer, fields *StructFields, ptr interface{}) error {
+	v := ptr.(*Version)
+	if err := fields.WriteField(f, buf, 0, reflect.ValueOf(&v.Label).Elem()); err != nil {
+		return err
+	}
+	buf.WriteInt8(NotNullValueFlag)
+	buf.WriteInt16(INT32)
+	buf.WriteInt32(v.Major)
+	buf.WriteInt8(NotNullValueFlag)
+	buf.WriteInt16(INT32)
+	buf.WriteInt32(v.Minor)
+	return nil
+}
+
+func (s versionSerializer) ReadFields(f *Fury, buf *ByteBuffer, fields *StructFields, ptr interface{}) error {
+	v := ptr.(*Version)
+	if err := fields.ReadField(f, buf, 0, reflect.ValueOf(&v.Label).Elem()); err != nil {
+		return err
+	}
+	if err := f.ReadFieldHeader(buf, INT32); err != nil {
+		return err
+	}
+	v.Major = buf.ReadInt32()
+	if err := f.ReadFieldHeader(buf, INT32); err != nil {
+		return err
+	}
+	v.Minor = buf.ReadInt32()
+	return nil
+}
+
+func TestGeneratedSerializer(t *testing.T) {
+	version := Version{Major: 1, Minor: 2, Label: "beta"}
+	reflectBytes, err := NewFury(WithLanguage(GO)).Marshal(version)
+	require.Nil(t, err)
+	RegisterGeneratedSerializer(Version{}, versionSerializer{fields: []string{"Label", "Major", "Minor"}})
+	for _, referenceTracking := range []bool{false, true} {
+		fury := NewFury(WithRefTracking(referenceTracking), WithLanguage(GO))
+		require.Nil(t, fury.RegisterType(Version{}))
+		bytes, err := fury.Marshal(version)
+		require.Nil(t, err)
+		if !referenceTracking {
+			require.Equal(t, reflectBytes, bytes)
+		}
+		for _, value := range []interface{}{version, &version, []Version{version, {}}, []interface{}{version}} {
+			serde(t, fury, value)
+		}
+	}
+	// generated serializer of different fields is rejected.
+	RegisterGeneratedSerializer(Version{}, versionSerializer{fields: []string{"Major", "Minor"}})
+	_, err = NewFury(WithLanguage(GO)).Marshal(version)
+	require.Error(t, err)
+	generatedSerializers.Lock()
+	delete(generatedSerializers.m, reflect.TypeOf(Version{}))
+	generatedSerializers.Unlock()
+	require.Panics(t, func() { RegisterGeneratedSerializer(1, versionSerializer{}) })
+}
+
+type Vector struct {
+	X, Y int32
+}
+
+// vectorSerializer writes vectors as two varints.
+type vectorSerializer struct {
+	typeId TypeId
+}
+
+func (s vectorSerializer) TypeId() TypeId {
+	return s.typeId
+}
+
+func (s vectorSerializer) Write(f *Fury, buf *ByteBuffer, value reflect.Value) error {
+	vector := value.Interface().(Vector)
+	buf.WriteSignedVarInt32(vector.X)
+	buf.WriteSignedVarInt32(vector.Y)
+	return nil
+}
+
+func (s vectorSerializer) Read(f *Fury, buf *ByteBuffer, type_ reflect.Type, value reflect.Value) error {
+	value.Set(reflect.ValueOf(Vector{X: buf.ReadSignedVarInt32(), Y: buf.ReadSignedVarInt32()}))
+	return nil
+}
+
+func TestRegisterSerializer(t *testing.T) {
+	type Line struct {
+		From, To Vector
+		Via      *Vector
+	}
+	vector := Vector{X: 1, Y: -2}
+	line := Line{From: vector, To: Vector{X: 3}, Via: &Vector{Y: 4}}
+	for _, typeId := range []TypeId{NotSupportCrossLanguage, 300} {
+		for _, language := range []Language{XLANG, GO} {
+			fury := NewFury(WithLanguage(language))
+			require.Nil(t, fury.RegisterSerializer(Vector{}, vectorSerializer{typeId: typeId}))
+			require.Nil(t, fury.RegisterType(Vector{}))
+			require.Nil(t, fury.RegisterTagType("example.Line", Line{}))
+			bytes, err := fury.Marshal(vector)
+			require.Nil(t, err)
+			newVector := Vector{}
+			require.Nil(t, fury.Unmarshal(bytes, &newVector))
+			require.Equal(t, vector, newVector)
+			for _, value := range []interface{}{vector, &vector, []Vector{vector, {}}, []interface{}{vector}, line} {
+				serde(t, fury, value)
+			}
+		}
+	}
+	fury := NewFury()
+	require.Nil(t, fury.RegisterSerializer(Vector{}, vectorSerializer{}))
+	require.Error(t, fury.RegisterSerializer(Vector{}, vectorSerializer{}))
+	require.Error(t, fury.RegisterSerializer(Line{}, vectorSerializer{typeId: STRING}))
+	require.Error(t, fury.RegisterSerializer(Line{}, vectorSerializer{typeId: EXTENSION}))
+	require.Error(t, fury.RegisterSerializer(Line{}, vectorSerializer{typeId: FURY_TYPE_TAG}))
+	require.Error(t, fury.RegisterSerializer(nil, vectorSerializer{}))
+}
+
+// Money writes its amount in cents and currency code.
+type Money struct {
+	Cents    int64
+	Currency string
+}
+
+func (m *Money) ExtId() int16 {
+	return 1
+}
+
+func (m *Money) MarshalFury(f *Fury, buf *ByteBuffer) error {
+	buf.WriteSignedVarInt64(m.Cents)
+	buf.WriteLength(len(m.Currency))
+	buf.WriteBinary([]byte(m.Currency))
+	return nil
+}
+
+func (m *Money) UnmarshalFury(f *Fury, buf *ByteBuffer) error {
+	m.Cents = buf.ReadSignedVarInt64()
+	m.Currency = string(buf.ReadBinary(buf.ReadLength()))
+	return nil
+}
+
+// Distance is a Marshaller with a value receiver.
+type Distance int64
+
+func (d Distance) ExtId() int16 {
+	return 2
+}
+
+func (d Distance) MarshalFury(f *Fury, buf *ByteBuffer) error {
+	buf.WriteInt64(int64(d))
+	return nil
+}
+
+func (d *Distance) UnmarshalFury(f *Fury, buf *ByteBuffer) error {
+	*d = Distance(buf.ReadInt64())
+	return nil
+}
+
+func TestMarshaller(t *testing.T) {
+	type Order struct {
+		Price    Money
+		Discount *Money
+		Fees     []Money
+		Extra    interface{}
+	}
+	price := Money{Cents: 1050, Currency: "USD"}
+	order := &Order{Price: price, Discount: &Money{Cents: -50, Currency: "USD"}, Fees: []Money{{}, price},
+		Extra: price}
+	for _, referenceTracking := range []bool{false, true} {
+		for _, mode := range []CompatibleMode{SCHEMA_CONSISTENT, COMPATIBLE} {
+			for _, language := range []Language{XLANG, GO} {
+				fury := NewFury(WithRefTracking(referenceTracking), WithCompatibleMode(mode), WithLanguage(language))
+				require.Nil(t, fury.RegisterTagType("example.Order", Order{}))
+				// slices of Money are read into interface values by golang type info.
+				require.Nil(t, fury.RegisterType(Money{}))
+				bytes, err := fury.Marshal(price)
+				require.Nil(t, err)
+				if language == XLANG {
+					// flag, type id, ext id, zigzag varint cents and currency.
+					require.Equal(t, append([]byte{0xff, byte(EXTENSION), 0, 1, 0, 180, 16, 3}, "USD"...),
+						bytes[len(bytes)-11:])
+				}
+				var newPrice Money
+				require.Nil(t, fury.Unmarshal(bytes, &newPrice))
+				require.Equal(t, price, newPrice)
+				var newOrder *Order
+				serDeserializeTo(t, fury, order, &newOrder)
+				for _, value := range []interface{}{price, []Money{price}, []interface{}{price, Money{}}} {
+					serde(t, fury, value)
+				}
+				// values of ext id are read into interface values as the registered type.
+				require.Nil(t, fury.RegisterType(&Money{}))
+				serde(t, fury, &price)
+				// data of other ext types can't be read into values.
+				bytes, err = NewFury(WithRefTracking(referenceTracking), WithLanguage(language)).Marshal(Distance(1))
+				require.Nil(t, err)
+				require.Error(t, fury.Unmarshal(bytes, &newPrice))
+			}
+		}
+	}
+	// Fee has the ext id of Money by the embedded Money.
+	type Fee struct {
+		Money
+	}
+	fury := NewFury()
+	require.Nil(t, fury.RegisterType(Money{}))
+	require.Error(t, fury.RegisterType(Fee{}))
+	serde(t, fury, Distance(3))
+}
+
+func TestSerializeConcreteSet(t *testing.T) {
+	type Tags struct {
+		Names map[string]struct{}
+		Ids   map[int64]bool `fury:",set"`
+	}
+	type PeerTags struct {
+		Names GenericSet
+		Ids   GenericSet
+	}
+	for _, referenceTracking := range []bool{false, true} {
+		for _, language := range []Language{XLANG, GO} {
+			fury := NewFury(WithRefTracking(referenceTracking), WithLanguage(language))
+			require.Nil(t, fury.RegisterTagType("example.Tags", Tags{}))
+			serializer, err := fury.typeResolver.getSerializerByType(reflect.TypeOf(map[string]struct{}{}))
+			require.Nil(t, err)
+			require.IsType(t, &setConcreteValueSerializer{}, serializer)
+			for _, value := range []interface{}{
+				map[string]struct{}{"a": {}, "b": {}}, map[string]struct{}{},
+				Tags{Names: map[string]struct{}{"a": {}}, Ids: map[int64]bool{1: true}},
+			} {
+				serde(t, fury, value)
+			}
+			// maps of bool values are maps unless they are fields with the set option.
+			serializer, err = fury.typeResolver.getSerializerByType(reflect.TypeOf(map[string]bool{}))
+			require.Nil(t, err)
+			require.NotEqual(t, FURY_SET, serializer.TypeId())
+			serde(t, fury, map[string]bool{"a": true, "b": false})
+			// keys of false values aren't in the set.
+			bytes, err := fury.Marshal(Tags{Ids: map[int64]bool{1: true, 2: false}})
+			require.Nil(t, err)
+			tags, err := DeserializeWith[Tags](fury, bytes)
+			require.Nil(t, err)
+			require.Equal(t, map[int64]bool{1: true}, tags.Ids)
+
+			// sets written by peers are read into typed sets.
+			peer := NewFury(WithRefTracking(referenceTracking), WithLanguage(language))
+			require.Nil(t, peer.RegisterTagType("example.Tags", PeerTags{}))
+			bytes, err = peer.Marshal(GenericSet{"a": true, "b": true})
+			require.Nil(t, err)
+			newNames, err := DeserializeWith[map[string]struct{}](fury, bytes)
+			require.Nil(t, err)
+			require.Equal(t, map[string]struct{}{"a": {}, "b": {}}, newNames)
+			bytes, err = peer.Marshal(PeerTags{Names: GenericSet{"a": true}, Ids: GenericSet{int64(1): true}})
+			require.Nil(t, err)
+			tags, err = DeserializeWith[Tags](fury, bytes)
+			require.Nil(t, err)
+			require.Equal(t, Tags{Names: map[string]struct{}{"a": {}}, Ids: map[int64]bool{1: true}}, tags)
+		}
+	}
+	type InvalidTags struct {
+		Ids map[int64]int32 `fury:",set"`
+	}
+	_, err := NewFury().Marshal(InvalidTags{})
+	require.Error(t, err)
+}
+
+func TestGenericSerialize(t *testing.T) {
+	bytes, err := Serialize(int32(-1))
+	require.Nil(t, err)
+	i, err := Deserialize[int32](bytes)
+	require.Nil(t, err)
+	require.Equal(t, int32(-1), i)
+	_, err = Deserialize[string](bytes)
+	require.Error(t, err)
+
+	fury := NewFury(WithLanguage(GO))
+	require.Nil(t, fury.RegisterType(Version{}))
+	version := Version{Major: 1, Label: "v1"}
+	bytes, err = SerializeWith(fury, &version)
+	require.Nil(t, err)
+	newVersion, err := DeserializeWith[*Version](fury, bytes)
+	require.Nil(t, err)
+	require.Equal(t, &version, newVersion)
+	value, err := DeserializeWith[interface{}](fury, bytes)
+	require.Nil(t, err)
+	require.Equal(t, &version, value)
+}
+
+func TestSerializeSetAndList(t *testing.T) {
+	type Group struct {
+		Ids     Set[int32]
+		Names   List[string]
+		Members List[*Version]
+		Values  List[interface{}]
+	}
+	member := &Version{Major: 1}
+	group := Group{
+		Ids:     NewSet[int32](1, 2, 3),
+		Names:   List[string]{"a", "b"},
+		Members: List[*Version]{member, member, nil},
+		Values:  List[interface{}]{"a", int64(1), nil},
+	}
+	for _, referenceTracking := range []bool{false, true} {
+		for _, language := range []Language{XLANG, GO} {
+			fury := NewFury(WithRefTracking(referenceTracking), WithLanguage(language))
+			require.Nil(t, fury.RegisterTagType("example.Group", Group{}))
+			require.Nil(t, fury.RegisterType(Version{}))
+			require.Nil(t, fury.RegisterType(Set[string]{}))
+			require.Nil(t, fury.RegisterType(List[int64]{}))
+			serializer, err := fury.typeResolver.getSerializerByType(reflect.TypeOf(Set[int32]{}))
+			require.Nil(t, err)
+			require.IsType(t, &typedSetSerializer[int32]{}, serializer)
+			serializer, err = fury.typeResolver.getSerializerByType(reflect.TypeOf(List[string]{}))
+			require.Nil(t, err)
+			require.IsType(t, &typedListSerializer[string]{}, serializer)
+			for _, value := range []interface{}{NewSet("a", "b"), Set[string]{}, List[int64]{1, -1}, List[int64]{}} {
+				serde(t, fury, value)
+			}
+			bytes, err := SerializeWith(fury, group)
+			require.Nil(t, err)
+			newGroup, err := DeserializeWith[Group](fury, bytes)
+			require.Nil(t, err)
+			require.Equal(t, group, newGroup)
+			if referenceTracking {
+				require.Same(t, newGroup.Members[0], newGroup.Members[1])
+			}
+			// sets written by peers are read into Set[T].
+			bytes, err = fury.Marshal(GenericSet{int32(1): true, int32(2): true})
+			require.Nil(t, err)
+			ids, err := DeserializeWith[Set[int32]](fury, bytes)
+			require.Nil(t, err)
+			require.Equal(t, NewSet[int32](1, 2), ids)
+			require.True(t, ids.Contains(2))
+		}
+	}
+}
+
+func corruptionTestFuries(t *testing.T) map[*Fury][]interface{} {
+	furies := map[*Fury][]interface{}{}
+	for _, referenceTracking := range []bool{false, true} {
+		for _, language := range []Language{XLANG, GO} {
+			fury := NewFury(WithRefTracking(referenceTracking), WithLanguage(language))
+			require.Nil(t, fury.RegisterTagType("example.Bar", Bar{}))
+			require.Nil(t, fury.RegisterTagType("example.Foo", Foo{}))
+			for _, value := range []interface{}{MyInt(0), MyString(""), MyIds{}, Point{}} {
+				require.Nil(t, fury.RegisterType(value))
+			}
+			foo := newFoo()
+			furies[fury] = []interface{}{
+				&foo, commonSlice(), commonMap(), []interface{}{"str", "str", []byte{1, 2}}, goData(),
+			}
+		}
+	}
+	return furies
+}
+
+func TestDeserializeTruncatedData(t *testing.T) {
+	for fury, values := range corruptionTestFuries(t) {
+		for _, value := range values {
+			bytes, err := fury.Marshal(value)
+			require.Nil(t, err)
+			for i := 0; i < len(bytes); i++ {
+				var newValue interface{}
+				err := fury.Unmarshal(bytes[:i], &newValue)
+				require.Error(t, err)
+				require.True(t, errors.Is(err, ErrBufferUnderflow) || errors.Is(err, ErrInvalidData), err)
+			}
+		}
+	}
+}
+
+func TestDeserializeMutatedData(t *testing.T) {
+	for fury, values := range corruptionTestFuries(t) {
+		for _, value := range values {
+			bytes, err := fury.Marshal(value)
+			require.Nil(t, err)
+			for i := 0; i < len(bytes); i++ {
+				for _, b := range []byte{0, 1, 0x7f, 0x80, 0xfe, 0xff, bytes[i] ^ 0x01} {
+					data := append([]byte(nil), bytes...)
+					data[i] = b
+					require.NotPanics(t, func() {
+						var newValue interface{}
+						_ = fury.Unmarshal(data, &newValue)
+						var newFoo Foo
+						_ = fury.Unmarshal(data, &newFoo)
+					})
+				}
+			}
+		}
+	}
+}
+
+func TestDeserializeInvalidData(t *testing.T) {
+	fury := NewFury(WithRefTracking(true))
+	bytes, err := fury.Marshal([]interface{}{"a", "a"})
+	require.Nil(t, err)
+	// the second element is a reference to the first element.
+	require.Equal(t, RefFlag, int8(bytes[len(bytes)-2]))
+	var newValue interface{}
+	bytes[len(bytes)-1] = 100
+	require.True(t, errors.Is(fury.Unmarshal(bytes, &newValue), ErrInvalidData))
+	bytes[len(bytes)-2] = 100
+	require.True(t, errors.Is(fury.Unmarshal(bytes, &newValue), ErrInvalidData))
+
+	bytes, err = fury.Marshal(int32(1))
+	require.Nil(t, err)
+	var str string
+	err = fury.Unmarshal(bytes, &str)
+	var invalidDataError *InvalidDataError
+	require.True(t, errors.As(err, &invalidDataError))
+	require.Greater(t, invalidDataError.Offset, 0)
+
+	var underflowError *BufferUnderflowError
+	require.True(t, errors.As(fury.Unmarshal(bytes[:len(bytes)-1], &newValue), &underflowError))
+	require.Equal(t, 3, underflowError.Remaining)
+	require.Equal(t, 4, underflowError.Size)
+	require.Error(t, fury.Unmarshal(bytes, newValue))
+
+	// values of other types than the value to read into are invalid data.
+	require.Nil(t, fury.RegisterTagType("example.Bar", Bar{}))
+	require.Nil(t, fury.RegisterTagType("example.Foo", Foo{}))
+	for _, value := range []interface{}{time.Unix(1, 0), newFoo(), map[int64]string{1: "a"}} {
+		bytes, err = fury.Marshal(value)
+		require.Nil(t, err)
+		var newDecimal Decimal
+		err = fury.Unmarshal(bytes, &newDecimal)
+		require.True(t, errors.Is(err, ErrInvalidData), err)
+		var newBar Bar
+		require.True(t, errors.Is(fury.Unmarshal(bytes, &newBar), ErrInvalidData))
+	}
+	// array lengths come from the data, arrays longer than the data are invalid data rather than allocated.
+	goFury := NewFury(WithLanguage(GO))
+	for _, typeInfo := range []string{"[99999999999999]int64", "[9223372036854775807]int64", "[2][99999]int64"} {
+		buffer := NewByteBuffer(nil)
+		buffer.WriteByte_(isLittleEndianFlag)
+		buffer.WriteInt8(NotNullValueFlag)
+		buffer.WriteInt16(-LIST)
+		require.Nil(t, newTypeResolver().writeMetaString(buffer, typeInfo))
+		buffer.WriteLength(2)
+		err = goFury.Unmarshal(buffer.GetByteSlice(0, buffer.WriterIndex()), &newValue)
+		require.True(t, errors.As(err, &invalidDataError), err)
+	}
+	// keys read into interfaces may not be hashable.
+	for key, hashable := range map[interface{}]bool{"a": true, [1]interface{}{1}: true} {
+		require.Equal(t, hashable, isHashable(reflect.ValueOf(&key).Elem()))
+	}
+	for _, key := range []interface{}{[]int{1}, [1]interface{}{map[string]int{}}} {
+		require.False(t, isHashable(reflect.ValueOf(&key).Elem()))
+	}
+	// panics which aren't caused by the data are raised again.
+	require.Panics(t, func() { _ = recoverReadError(errors.New("bug")) })
+}
+
+func TestDeserializeLimits(t *testing.T) {
+	checkLimit := func(fury *Fury, value interface{}, limit string) {
+		bytes, err := NewFury().Marshal(value)
+		require.Nil(t, err)
+		var newValue interface{}
+		err = fury.Unmarshal(bytes, &newValue)
+		require.True(t, errors.Is(err, ErrLimitExceeded), err)
+		var limitError *LimitError
+		require.True(t, errors.As(err, &limitError))
+		require.Equal(t, limit, limitError.Limit)
+	}
+
+	var nested interface{} = "str"
+	for i := 0; i < DefaultMaxDepth; i++ {
+		nested = []interface{}{nested}
+	}
+	checkLimit(NewFury(), nested, "depth")
+	serde(t, NewFury(WithMaxDepth(DefaultMaxDepth+1)), nested)
+
+	fury := NewFury(WithMaxCollectionSize(2))
+	for _, value := range []interface{}{
+		[]interface{}{"a", "b", "c"}, []int64{1, 2, 3}, []bool{true, true, true},
+		map[string]string{"k1": "v1", "k2": "v2", "k3": "v3"},
+	} {
+		checkLimit(fury, value, "collection size")
+	}
+	serde(t, fury, []interface{}{"a", "b"})
+
+	fury = NewFury(WithMaxBinarySize(3))
+	checkLimit(fury, "abcd", "binary size")
+	checkLimit(fury, []byte{1, 2, 3, 4}, "binary size")
+	serde(t, fury, "abc")
+
+	fury = NewFury(WithMaxTotalBytes(100))
+	checkLimit(fury, make([]int64, 100), "total bytes")
+	checkLimit(fury, []interface{}{make([]int32, 10), make([]int32, 10), make([]int32, 10)}, "total bytes")
+	serde(t, fury, make([]int64, 5))
+}
+
 func TestSerializeStringReference(t *testing.T) {
-	fury := NewFury(true)
+	fury := NewFury(WithRefTracking(true))
 	strSlice := []string{"str1", "str1", "", "", "str2"}
 	strSlice = append(strSlice, strSlice[0])
 	serde(t, fury, strSlice)
@@ -343,7 +1492,7 @@ func TestSerializeStringReference(t *testing.T) {
 }
 
 func TestSerializeCircularReference(t *testing.T) {
-	fury := NewFury(true)
+	fury := NewFury(WithRefTracking(true))
 	{
 		type A struct {
 			A1 *A
@@ -384,7 +1533,7 @@ func TestSerializeCircularReference(t *testing.T) {
 }
 
 func TestSerializeComplexReference(t *testing.T) {
-	fury := NewFury(true)
+	fury := NewFury(WithRefTracking(true))
 	type A struct {
 		F1 string
 		F2 *A
@@ -424,7 +1573,7 @@ func TestSerializeComplexReference(t *testing.T) {
 }
 
 func TestSerializeCommonReference(t *testing.T) {
-	fury := NewFury(true)
+	fury := NewFury(WithRefTracking(true))
 	var values []interface{}
 	values = append(values, commonSlice()...)
 	values = append(values, commonMap()...)
@@ -441,7 +1590,7 @@ func TestSerializeCommonReference(t *testing.T) {
 }
 
 func TestSerializeZeroCopy(t *testing.T) {
-	fury := NewFury(true)
+	fury := NewFury(WithRefTracking(true))
 	list := []interface{}{"str", make([]byte, 1000)}
 	buf := NewByteBuffer(nil)
 	var bufferObjects []BufferObject
@@ -460,6 +1609,57 @@ func TestSerializeZeroCopy(t *testing.T) {
 	require.Equal(t, list, newList)
 }
 
+func TestMarshalOwnsData(t *testing.T) {
+	fury := NewFury()
+	bytes1, err := fury.Marshal("str1")
+	require.Nil(t, err)
+	bytes2, err := fury.Marshal("str2")
+	require.Nil(t, err)
+	var str1, str2 string
+	require.Nil(t, fury.Unmarshal(bytes1, &str1))
+	require.Nil(t, fury.Unmarshal(bytes2, &str2))
+	require.Equal(t, "str1", str1)
+	require.Equal(t, "str2", str2)
+
+	bytes1, err = Marshal("str1")
+	require.Nil(t, err)
+	bytes2, err = Marshal("str2")
+	require.Nil(t, err)
+	require.Nil(t, Unmarshal(bytes1, &str1))
+	require.Nil(t, Unmarshal(bytes2, &str2))
+	require.Equal(t, "str1", str1)
+	require.Equal(t, "str2", str2)
+}
+
+func TestMarshalAppend(t *testing.T) {
+	fury := NewFury()
+	prefix := []byte{1, 2, 3}
+	data, err := fury.MarshalAppend(prefix, []string{"str1", "str2"})
+	require.Nil(t, err)
+	require.Equal(t, prefix, data[:len(prefix)])
+	expected, err := fury.Marshal([]string{"str1", "str2"})
+	require.Nil(t, err)
+	require.Equal(t, expected, data[len(prefix):])
+	var newValue []string
+	require.Nil(t, fury.Unmarshal(data[len(prefix):], &newValue))
+	require.Equal(t, []string{"str1", "str2"}, newValue)
+
+	data, err = MarshalAppend(nil, int32(1))
+	require.Nil(t, err)
+	var i int32
+	require.Nil(t, Unmarshal(data, &i))
+	require.Equal(t, int32(1), i)
+
+	buf := make([]byte, 0, 1024)
+	var value interface{} = []interface{}{"str", int64(1), []int32{1, 2}}
+	allocs := testing.AllocsPerRun(100, func() {
+		if _, err := fury.MarshalAppend(buf[:0], value); err != nil {
+			panic(err)
+		}
+	})
+	require.Equal(t, float64(0), allocs)
+}
+
 func serDeserializeTo(t *testing.T, fury *Fury, value interface{}, to interface{}) {
 	bytes, err := fury.Marshal(value)
 	require.Nil(t, err, fmt.Sprintf("serialize value %s with type %s failed: %s",
@@ -497,7 +1697,7 @@ func serde(t *testing.T, fury *Fury, value interface{}) {
 //  go tool pprof -text -nodecount=10 ./fury.test mem.out
 
 func BenchmarkMarshal(b *testing.B) {
-	fury := NewFury(true)
+	fury := NewFury(WithRefTracking(true))
 	require.Nil(b, fury.RegisterTagType("example.Foo", Foo{}))
 	require.Nil(b, fury.RegisterTagType("example.Bar", Bar{}))
 	value := benchData()
@@ -510,7 +1710,7 @@ func BenchmarkMarshal(b *testing.B) {
 }
 
 func BenchmarkUnmarshal(b *testing.B) {
-	fury := NewFury(true)
+	fury := NewFury(WithRefTracking(true))
 	require.Nil(b, fury.RegisterTagType("example.Foo", Foo{}))
 	require.Nil(b, fury.RegisterTagType("example.Bar", Bar{}))
 	value := benchData()
@@ -82,7 +82,7 @@ func TestBuffer(t *testing.T) {
 }
 
 func TestXLangSerializer(t *testing.T) {
-	fury_ := fury.NewFury(true)
+	fury_ := fury.NewFury(fury.WithRefTracking(true))
 	buffer := fury.NewByteBuffer(nil)
 	require.Nil(t, fury_.Serialize(buffer, true, nil))
 	require.Nil(t, fury_.Serialize(buffer, false, nil))
@@ -163,7 +163,7 @@ func TestXLangSerializer(t *testing.T) {
 }
 
 func TestCrossLanguageReference(t *testing.T) {
-	fury_ := fury.NewFury(true)
+	fury_ := fury.NewFury(fury.WithRefTracking(true))
 	list := make([]interface{}, 2, 2)
 	dict := map[interface{}]interface{}{}
 	list[0] = list
@@ -228,7 +228,7 @@ type ComplexObject2 struct {
 }
 
 func TestSerializeSimpleStruct(t *testing.T) {
-	fury_ := fury.NewFury(true)
+	fury_ := fury.NewFury(fury.WithRefTracking(true))
 	require.Nil(t, fury_.RegisterTagType("test.ComplexObject2", ComplexObject2{}))
 	obj2 := &ComplexObject2{}
 	obj2.F1 = true
@@ -237,7 +237,7 @@ func TestSerializeSimpleStruct(t *testing.T) {
 }
 
 func TestSerializeComplexStruct(t *testing.T) {
-	fury_ := fury.NewFury(true)
+	fury_ := fury.NewFury(fury.WithRefTracking(true))
 	require.Nil(t, fury_.RegisterTagType("test.ComplexObject1", ComplexObject1{}))
 	require.Nil(t, fury_.RegisterTagType("test.ComplexObject2", ComplexObject2{}))
 	obj2 := &ComplexObject2{}
@@ -286,7 +286,7 @@ func structRoundBack(t *testing.T, fury_ *fury.Fury, obj interface{}, testName s
 }
 
 func TestOutOfBandBuffer(t *testing.T) {
-	fury_ := fury.NewFury(true)
+	fury_ := fury.NewFury(fury.WithRefTracking(true))
 	var data [][]byte
 	for i := 0; i < 10; i++ {
 		data = append(data, []byte{0, 1})
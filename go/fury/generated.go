@@ -0,0 +1,123 @@
+// Licensed to the Apache Software Foundation (ASF) under one
+// or more contributor license agreements.  See the NOTICE file
+// distributed with this work for additional information
+// regarding copyright ownership.  The ASF licenses this file
+// to you under the Apache License, Version 2.0 (the
+// "License"); you may not use this file except in compliance
+// with the License.  You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package fury
+
+import (
+	"fmt"
+	"reflect"
+	"sync"
+)
+
+// GeneratedSerializer writes and reads the fields of a struct without reflection, which is generated by
+// `furygen` and registered by RegisterGeneratedSerializer. The struct hash, type tag and type def are still
+// written by the struct serializer, so the data is the same as the data written by reflection.
+type GeneratedSerializer interface {
+	// Fields returns the golang names of the serialized fields in the order they are written, which must be
+	// the same as the fields order of the struct serializer.
+	Fields() []string
+	// WriteFields writes the fields of the struct pointed to by `ptr`. Fields which aren't generated are
+	// written by `fields`.
+	WriteFields(f *Fury, buf *ByteBuffer, fields *StructFields, ptr interface{}) error
+	// ReadFields reads the fields into the struct pointed to by `ptr`. Fields which aren't generated are
+	// read by `fields`.
+	ReadFields(f *Fury, buf *ByteBuffer, fields *StructFields, ptr interface{}) error
+}
+
+var generatedSerializers = struct {
+	sync.RWMutex
+	m map[reflect.Type]GeneratedSerializer
+}{m: map[reflect.Type]GeneratedSerializer{}}
+
+// RegisterGeneratedSerializer registers the generated serializer of the struct type of `v`, which is used by
+// all Fury instances to serialize the struct and pointers to the struct. It's called by the `init` function
+// of generated code.
+func RegisterGeneratedSerializer(v interface{}, s GeneratedSerializer) {
+	type_ := reflect.TypeOf(v)
+	if type_ == nil || type_.Kind() != reflect.Struct {
+		panic(fmt.Errorf("generated serializer can only be registered for struct, but got %T", v))
+	}
+	generatedSerializers.Lock()
+	defer generatedSerializers.Unlock()
+	generatedSerializers.m[type_] = s
+}
+
+func getGeneratedSerializer(type_ reflect.Type) GeneratedSerializer {
+	generatedSerializers.RLock()
+	defer generatedSerializers.RUnlock()
+	return generatedSerializers.m[type_]
+}
+
+// StructFields writes and reads the fields of a struct which aren't generated, such as slices, maps and
+// nested structs, by the serializers of the struct serializer.
+type StructFields struct {
+	fieldsInfo structFieldsInfo
+}
+
+// WriteField writes `value` of the i-th field in the fields order.
+func (s *StructFields) WriteField(f *Fury, buf *ByteBuffer, i int, value reflect.Value) error {
+	return writeField(f, buf, s.fieldsInfo[i], value)
+}
+
+// ReadField reads the i-th field in the fields order into `value`.
+func (s *StructFields) ReadField(f *Fury, buf *ByteBuffer, i int, value reflect.Value) error {
+	fieldInfo_ := s.fieldsInfo[i]
+	return readField(f, buf, value, fieldInfo_.serializer, fieldInfo_.nullable, fieldInfo_.referencable)
+}
+
+// ReadFieldHeader reads the not null flag and type id of a field which isn't referencable, such as a field of
+// bool or int32, an error is returned if they aren't the flag and `typeId`. It's used by generated serializers.
+func (f *Fury) ReadFieldHeader(buf *ByteBuffer, typeId TypeId) error {
+	if flag := buf.ReadInt8(); flag != NotNullValueFlag {
+		return invalidDataError(buf, "should be a byte value `%d` here but got `%d`", NotNullValueFlag, flag)
+	}
+	if id := buf.ReadInt16(); id != typeId {
+		return invalidDataError(buf, "field of type id %d can't be read as type id %d", id, typeId)
+	}
+	return nil
+}
+
+// WriteStringField writes a string field with its reference flag and type id. It's used by generated
+// serializers.
+func (f *Fury) WriteStringField(buf *ByteBuffer, value string) error {
+	if f.referenceTracking {
+		return f.writeReferencableBySerializer(buf, reflect.ValueOf(value), stringSerializer{})
+	}
+	buf.WriteInt8(NotNullValueFlag)
+	buf.WriteInt16(STRING)
+	return writeString(f, buf, value)
+}
+
+// ReadStringField reads a string field written by WriteStringField. It's used by generated serializers.
+func (f *Fury) ReadStringField(buf *ByteBuffer) (string, error) {
+	if f.referenceTracking {
+		var value string
+		err := f.readReferencableBySerializer(buf, reflect.ValueOf(&value).Elem(), stringSerializer{})
+		return value, err
+	}
+	switch flag := buf.ReadInt8(); flag {
+	case NullFlag:
+		return "", nil
+	case NotNullValueFlag:
+	default:
+		return "", invalidDataError(buf, "unexpected flag %d of string", flag)
+	}
+	if id := buf.ReadInt16(); id != STRING {
+		return "", invalidDataError(buf, "field of type id %d can't be read as type id %d", id, STRING)
+	}
+	return readString(f, buf), nil
+}
@@ -44,6 +44,8 @@ func (s mapSerializer) Write(f *Fury, buf *ByteBuffer, value reflect.Value) erro
 }
 
 func (s mapSerializer) Read(f *Fury, buf *ByteBuffer, type_ reflect.Type, value reflect.Value) error {
+	// maps of peers may be read into typed maps.
+	type_ = value.Type()
 	if value.IsNil() {
 		value.Set(reflect.MakeMap(type_))
 	}
@@ -51,11 +53,15 @@ func (s mapSerializer) Read(f *Fury, buf *ByteBuffer, type_ reflect.Type, value
 	keyType := type_.Key()
 	valueType := type_.Elem()
 	length := f.readLength(buf)
+	f.allocate(buf, length*int(keyType.Size()+valueType.Size()))
 	for i := 0; i < length; i++ {
 		mapKey := reflect.New(keyType).Elem()
 		if err := f.ReadReferencable(buf, mapKey); err != nil {
 			return err
 		}
+		if !isHashable(mapKey) {
+			return invalidDataError(buf, "map key %v isn't hashable", mapKey)
+		}
 		mapValue := reflect.New(valueType).Elem()
 		if err := f.ReadReferencable(buf, mapValue); err != nil {
 			return err
@@ -116,6 +122,7 @@ func (s *mapConcreteKeyValueSerializer) Read(f *Fury, buf *ByteBuffer, type_ ref
 	keyType := s.type_.Key()
 	valueType := s.type_.Elem()
 	length := f.readLength(buf)
+	f.allocate(buf, length*int(keyType.Size()+valueType.Size()))
 	for i := 0; i < length; i++ {
 		mapKey := reflect.New(keyType).Elem()
 		if s.keySerializer != nil {
@@ -126,6 +133,9 @@ func (s *mapConcreteKeyValueSerializer) Read(f *Fury, buf *ByteBuffer, type_ ref
 			if err := f.ReadReferencable(buf, mapKey); err != nil {
 				return err
 			}
+			if !isHashable(mapKey) {
+				return invalidDataError(buf, "map key %v isn't hashable", mapKey)
+			}
 		}
 		mapValue := reflect.New(valueType).Elem()
 		if s.valueSerializer != nil {
@@ -141,3 +151,28 @@ func (s *mapConcreteKeyValueSerializer) Read(f *Fury, buf *ByteBuffer, type_ ref
 	}
 	return nil
 }
+
+// isHashable returns whether `key` can be a map key. Keys read into interfaces may hold values of types which
+// aren't comparable, such as slices, which panic when they are hashed.
+func isHashable(key reflect.Value) bool {
+	switch key.Kind() {
+	case reflect.Interface:
+		return key.IsNil() || isHashable(key.Elem())
+	case reflect.Array:
+		for i := 0; i < key.Len(); i++ {
+			if !isHashable(key.Index(i)) {
+				return false
+			}
+		}
+		return true
+	case reflect.Struct:
+		for i := 0; i < key.NumField(); i++ {
+			if !isHashable(key.Field(i)) {
+				return false
+			}
+		}
+		return true
+	default:
+		return key.Type().Comparable()
+	}
+}
@@ -49,7 +49,7 @@ func (d *Decoder) Decode(data []byte, encoding Encoding) (result string, err err
 		chars, err = d.decodeGeneric(data, encoding)
 	case FIRST_TO_LOWER_SPECIAL:
 		chars, err = d.decodeGeneric(data, LOWER_SPECIAL)
-		if err == nil {
+		if err == nil && len(chars) > 0 {
 			chars[0] = chars[0] - 'a' + 'A'
 		}
 	case ALL_TO_LOWER_SPECIAL:
@@ -111,6 +111,9 @@ func (d *Decoder) decodeRepAllToLowerSpecial(data []byte, algorithm Encoding) ([
 	j := 0
 	for i := 0; i < len(str); i++ {
 		if str[i] == '|' {
+			if i+1 >= len(str) || str[i+1] < 'a' || str[i+1] > 'z' {
+				return nil, fmt.Errorf("invalid upper case escape in ALL_TO_LOWER_SPECIAL: %s", str)
+			}
 			chars[j] = str[i+1] - 'a' + 'A'
 			i++
 		} else {
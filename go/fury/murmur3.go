@@ -0,0 +1,97 @@
+// Licensed to the Apache Software Foundation (ASF) under one
+// or more contributor license agreements.  See the NOTICE file
+// distributed with this work for additional information
+// regarding copyright ownership.  The ASF licenses this file
+// to you under the Apache License, Version 2.0 (the
+// "License"); you may not use this file except in compliance
+// with the License.  You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package fury
+
+import (
+	"encoding/binary"
+	"math/bits"
+)
+
+// murmurHashSeed is the seed of MurmurHash3 used by all fury runtimes, such as hashes of meta strings.
+const murmurHashSeed = 47
+
+const (
+	murmurC1 = 0x87c37b91114253d5
+	murmurC2 = 0x4cf5ad432745937f
+)
+
+// murmurHash3_x64_128 returns the 128 bits MurmurHash3 of `data` for x64, which is the same as
+// `MurmurHash3.murmurhash3_x64_128` of java and `mmh3.hash_buffer` of python.
+func murmurHash3_x64_128(data []byte, seed uint32) (uint64, uint64) {
+	h1, h2 := uint64(seed), uint64(seed)
+	length := len(data)
+	for len(data) >= 16 {
+		k1 := binary.LittleEndian.Uint64(data)
+		k2 := binary.LittleEndian.Uint64(data[8:])
+		data = data[16:]
+		h1 ^= murmurMixK1(k1)
+		h1 = bits.RotateLeft64(h1, 27)
+		h1 += h2
+		h1 = h1*5 + 0x52dce729
+		h2 ^= murmurMixK2(k2)
+		h2 = bits.RotateLeft64(h2, 31)
+		h2 += h1
+		h2 = h2*5 + 0x38495ab5
+	}
+	// the tail of less than 16 bytes, whose bytes after the 8th are mixed into h2.
+	if len(data) > 8 {
+		var k2 uint64
+		for i := len(data) - 1; i >= 8; i-- {
+			k2 = k2<<8 | uint64(data[i])
+		}
+		h2 ^= murmurMixK2(k2)
+		data = data[:8]
+	}
+	if len(data) > 0 {
+		var k1 uint64
+		for i := len(data) - 1; i >= 0; i-- {
+			k1 = k1<<8 | uint64(data[i])
+		}
+		h1 ^= murmurMixK1(k1)
+	}
+	h1 ^= uint64(length)
+	h2 ^= uint64(length)
+	h1 += h2
+	h2 += h1
+	h1 = murmurFmix64(h1)
+	h2 = murmurFmix64(h2)
+	h1 += h2
+	h2 += h1
+	return h1, h2
+}
+
+func murmurMixK1(k1 uint64) uint64 {
+	k1 *= murmurC1
+	k1 = bits.RotateLeft64(k1, 31)
+	return k1 * murmurC2
+}
+
+func murmurMixK2(k2 uint64) uint64 {
+	k2 *= murmurC2
+	k2 = bits.RotateLeft64(k2, 33)
+	return k2 * murmurC1
+}
+
+func murmurFmix64(k uint64) uint64 {
+	k ^= k >> 33
+	k *= 0xff51afd7ed558ccd
+	k ^= k >> 33
+	k *= 0xc4ceb9fe1a85ec53
+	k ^= k >> 33
+	return k
+}
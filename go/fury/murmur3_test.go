@@ -0,0 +1,62 @@
+// Licensed to the Apache Software Foundation (ASF) under one
+// or more contributor license agreements.  See the NOTICE file
+// distributed with this work for additional information
+// regarding copyright ownership.  The ASF licenses this file
+// to you under the Apache License, Version 2.0 (the
+// "License"); you may not use this file except in compliance
+// with the License.  You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package fury
+
+import (
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestMurmurHash3(t *testing.T) {
+	// `mmh3.hash_buffer(bytearray([1, 2, 3]), seed=47)[0]` of python.
+	h1, _ := murmurHash3_x64_128([]byte{1, 2, 3}, murmurHashSeed)
+	require.Equal(t, int64(-7373655978913577904), int64(h1))
+	h1, h2 := murmurHash3_x64_128([]byte("hello"), 0)
+	require.Equal(t, []uint64{0xcbd8a7b341bd9b02, 0x5b1e906a48ae1d19}, []uint64{h1, h2})
+	h1, h2 = murmurHash3_x64_128(nil, 0)
+	require.Equal(t, []uint64{0, 0}, []uint64{h1, h2})
+	// blocks of 16 bytes and tails longer than 8 bytes.
+	data := make([]byte, 64)
+	for i := range data {
+		data[i] = byte(i)
+	}
+	h1, h2 = murmurHash3_x64_128(data, murmurHashSeed)
+	require.Equal(t, []uint64{0x8274481185fd7043, 0xcf9adfa59e7369a1}, []uint64{h1, h2})
+	h1, h2 = murmurHash3_x64_128(data[:31], murmurHashSeed)
+	require.Equal(t, []uint64{0xca07e9444c32db4b, 0xf3d3d350825f1c8e}, []uint64{h1, h2})
+}
+
+func TestMetaStringHash(t *testing.T) {
+	// hashes computed by the algorithm of `MetaStringBytes` of java.
+	for str, hash := range map[string]int64{
+		"example.model.events.UserCreated":            939560037506293764,
+		"org.apache.fury.benchmark.data.MediaContent": 1266103192606486020,
+		"com_example_UPPER.Name":                      1150850636056989954,
+	} {
+		metaString, err := typeMetaStringEncoder.Encode(str)
+		require.Nil(t, err)
+		metaStr := newMetaStringBytes(metaString)
+		require.Greater(t, len(metaStr.data), SMALL_STRING_THRESHOLD)
+		require.Equal(t, hash, metaStr.hash)
+
+		buffer := NewByteBuffer(nil)
+		require.Nil(t, newTypeResolver().writeMetaString(buffer, str))
+		buffer.ReadVarInt32()
+		require.Equal(t, hash, buffer.ReadInt64())
+	}
+}
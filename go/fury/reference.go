@@ -92,7 +92,7 @@ func (r *RefResolver) WriteRefOrNull(buffer *ByteBuffer, value reflect.Value) (r
 		return r.WriteRefOrNull(buffer, value)
 	case reflect.String:
 		isNil = false
-		str := unsafeGetBytes(value.Interface().(string))
+		str := unsafeGetBytes(value.String())
 		value = reflect.ValueOf(str)
 		length = len(str)
 	case reflect.Invalid:
@@ -166,11 +166,18 @@ func (r *RefResolver) TryPreserveRefId(buffer *ByteBuffer) (int32, error) {
 	if headFlag == RefFlag {
 		// read ref id and get object from ref resolver
 		refId := buffer.ReadVarInt32()
+		if r.refTracking && (refId < 0 || int(refId) >= len(r.readObjects)) {
+			return 0, invalidDataError(buffer, "ref id %d out of range [0, %d)", refId, len(r.readObjects))
+		}
 		r.readObject = r.GetReadObject(refId)
 	} else {
 		r.readObject = reflect.Value{}
-		if headFlag == RefValueFlag {
+		switch headFlag {
+		case RefValueFlag:
 			return r.PreserveRefId()
+		case NullFlag, NotNullValueFlag:
+		default:
+			return 0, invalidDataError(buffer, "unknown ref flag %d", headFlag)
 		}
 	}
 	// `headFlag` except `REF_FLAG` can be used as stub ref id because we use
@@ -185,14 +192,31 @@ func (r *RefResolver) Reference(value reflect.Value) {
 		return
 	}
 	length := len(r.readRefIds)
+	if length == 0 {
+		// no ref id was preserved since the data doesn't carry ref flag of the object.
+		return
+	}
 	refId := r.readRefIds[length-1]
 	r.readRefIds = r.readRefIds[:length-1]
 	r.SetReadObject(refId, value)
 }
 
-// GetReadObject returns the object for the specified id.
+// preserveStubRefId preserves a ref id which doesn't refer to any object, it's used by objects which don't
+// have a ref flag in the data.
+func (r *RefResolver) preserveStubRefId() {
+	r.readRefIds = append(r.readRefIds, -1)
+}
+
+// releaseStubRefId removes the stub ref id if it isn't taken by `Reference`.
+func (r *RefResolver) releaseStubRefId() {
+	if length := len(r.readRefIds); length > 0 && r.readRefIds[length-1] == -1 {
+		r.readRefIds = r.readRefIds[:length-1]
+	}
+}
+
+// GetReadObject returns the object for the specified id, or an invalid value if the id is out of range.
 func (r *RefResolver) GetReadObject(refId int32) reflect.Value {
-	if !r.refTracking {
+	if !r.refTracking || refId < 0 || int(refId) >= len(r.readObjects) {
 		return reflect.Value{}
 	}
 	return r.readObjects[refId]
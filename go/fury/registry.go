@@ -0,0 +1,114 @@
+// Licensed to the Apache Software Foundation (ASF) under one
+// or more contributor license agreements.  See the NOTICE file
+// distributed with this work for additional information
+// regarding copyright ownership.  The ASF licenses this file
+// to you under the Apache License, Version 2.0 (the
+// "License"); you may not use this file except in compliance
+// with the License.  You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package fury
+
+import (
+	"fmt"
+	"reflect"
+	"sync"
+	"sync/atomic"
+)
+
+type registeredType struct {
+	tag   string
+	type_ reflect.Type
+}
+
+// registry holds the struct types registered by `Register` in registration order. `count` is the number of
+// registered types, which is read without the lock by Fury instances to check for new types.
+var registry = struct {
+	sync.RWMutex
+	count  int32
+	frozen bool
+	types  []registeredType
+	tags   map[string]reflect.Type
+	byType map[reflect.Type]string
+}{tags: map[string]reflect.Type{}, byType: map[reflect.Type]string{}}
+
+// Register registers the struct type of `v` by `tag` for all Fury instances, including the instances behind
+// `Marshal` and `Unmarshal`, such as `gob.Register`. It's intended to be called by `init` functions, so packages
+// can register their types without a Fury instance. Registering the same type by the same tag again is a no-op,
+// but Fury instances can't register the type by `RegisterTagType` any more. Register panics if the tag or the type
+// has been registered differently, or the registry has been frozen by `FreezeRegistry`.
+//
+// Types and tags which have been registered to a Fury instance take precedence over the conflicting ones of
+// Register, which are skipped by that instance.
+func Register(tag string, v interface{}) {
+	if err := register(tag, reflect.TypeOf(v)); err != nil {
+		panic(err)
+	}
+}
+
+func register(tag string, type_ reflect.Type) error {
+	if type_ == nil || type_.Kind() != reflect.Struct {
+		return fmt.Errorf("only struct types can be registered, but got %v", type_)
+	}
+	if tag == "" {
+		return fmt.Errorf("type %s must be registered by a non-empty tag", type_)
+	}
+	registry.Lock()
+	defer registry.Unlock()
+	if registry.frozen {
+		return fmt.Errorf("can't register type %s by tag %s since the registry is frozen", type_, tag)
+	}
+	if prev, ok := registry.tags[tag]; ok {
+		if prev == type_ {
+			return nil
+		}
+		return fmt.Errorf("tag %s has been registered by type %s", tag, prev)
+	}
+	if prev, ok := registry.byType[type_]; ok {
+		return fmt.Errorf("type %s has been registered by tag %s", type_, prev)
+	}
+	// types which have builtin serializers, such as Date, can't be registered.
+	if err := newTypeResolver().RegisterTypeTag(type_, tag); err != nil {
+		return err
+	}
+	registry.types = append(registry.types, registeredType{tag: tag, type_: type_})
+	registry.tags[tag] = type_
+	registry.byType[type_] = tag
+	atomic.StoreInt32(&registry.count, int32(len(registry.types)))
+	return nil
+}
+
+// FreezeRegistry forbids further calls of `Register`, which is intended to be called by `main` after all packages
+// have been initialized, so types can't be registered by tags of other types at runtime.
+func FreezeRegistry() {
+	registry.Lock()
+	defer registry.Unlock()
+	registry.frozen = true
+}
+
+// registerGlobalTypes registers the types of `Register` which haven't been registered to `r`. Types and tags which
+// have been registered to `r`, or whose serializers have been created by `r`, are skipped.
+func (r *typeResolver) registerGlobalTypes() {
+	if atomic.LoadInt32(&registry.count) == r.globalTypes {
+		return
+	}
+	registry.RLock()
+	defer registry.RUnlock()
+	for _, t := range registry.types[r.globalTypes:] {
+		_, typeRegistered := r.typeToSerializers[t.type_]
+		_, tagRegistered := r.typeTagToSerializers[t.tag]
+		if !typeRegistered && !tagRegistered {
+			// can't fail since neither the type nor the tag has been registered.
+			_ = r.RegisterTypeTag(t.type_, t.tag)
+		}
+		r.globalTypes++
+	}
+}
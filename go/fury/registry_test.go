@@ -0,0 +1,83 @@
+// Licensed to the Apache Software Foundation (ASF) under one
+// or more contributor license agreements.  See the NOTICE file
+// distributed with this work for additional information
+// regarding copyright ownership.  The ASF licenses this file
+// to you under the Apache License, Version 2.0 (the
+// "License"); you may not use this file except in compliance
+// with the License.  You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package fury
+
+import (
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+type RegisteredOrder struct {
+	Id    int64
+	Items []string
+}
+
+func TestRegister(t *testing.T) {
+	fury := NewFury(WithRefTracking(true))
+	order := RegisteredOrder{Id: 1, Items: []string{"a", "b"}}
+	// instances created before registration see the type too.
+	Register("example.RegisteredOrder", RegisteredOrder{})
+	Register("example.RegisteredOrder", RegisteredOrder{})
+	for _, fury := range []*Fury{fury, NewFury(WithRefTracking(true))} {
+		bytes, err := fury.Marshal(order)
+		require.Nil(t, err)
+		var v interface{}
+		require.Nil(t, fury.Unmarshal(bytes, &v))
+		require.Equal(t, order, v)
+	}
+	bytes, err := Marshal(&order)
+	require.Nil(t, err)
+	var v interface{}
+	require.Nil(t, Unmarshal(bytes, &v))
+	require.Equal(t, &order, v)
+	require.Error(t, NewFury().RegisterTagType("example.RegisteredOrder", RegisteredOrder{}))
+
+	type Other struct{}
+	require.Panics(t, func() { Register("example.RegisteredOrder", Other{}) })
+	require.Panics(t, func() { Register("example.Other", RegisteredOrder{}) })
+	require.Panics(t, func() { Register("example.Other", &Other{}) })
+	require.Panics(t, func() { Register("", Other{}) })
+	require.Panics(t, func() { Register("example.Date", Date{}) })
+
+	// types and tags registered locally take precedence over the conflicting global ones.
+	type Local struct{ Id int32 }
+	type Shadowed struct{ Name string }
+	type Shadowing struct{ Name string }
+	fury = NewFury()
+	require.Nil(t, fury.RegisterTagType("example.Local", Local{}))
+	require.Nil(t, fury.RegisterTagType("example.Shadowed", Shadowing{}))
+	Register("example.LocalGlobal", Local{})
+	Register("example.Shadowed", Shadowed{})
+	for _, value := range []interface{}{1, Local{Id: 1}} {
+		serde(t, fury, value)
+	}
+	bytes, err = fury.Marshal(&Local{Id: 2})
+	require.Nil(t, err)
+	require.Nil(t, fury.Unmarshal(bytes, &v))
+	require.Equal(t, &Local{Id: 2}, v)
+	bytes, err = fury.Marshal(&Shadowing{Name: "a"})
+	require.Nil(t, err)
+	require.Nil(t, fury.Unmarshal(bytes, &v))
+	require.Equal(t, &Shadowing{Name: "a"}, v)
+
+	FreezeRegistry()
+	defer func() {
+		registry.frozen = false
+	}()
+	require.Panics(t, func() { Register("example.Other", Other{}) })
+}
@@ -18,9 +18,10 @@
 package fury
 
 import (
+	"encoding/binary"
 	"fmt"
 	"reflect"
-	"time"
+	"unicode/utf16"
 )
 
 type Serializer interface {
@@ -42,7 +43,7 @@ func (s boolSerializer) Write(f *Fury, buf *ByteBuffer, value reflect.Value) err
 }
 
 func (s boolSerializer) Read(f *Fury, buf *ByteBuffer, type_ reflect.Type, value reflect.Value) error {
-	value.Set(reflect.ValueOf(buf.ReadBool()))
+	value.SetBool(buf.ReadBool())
 	return nil
 }
 
@@ -59,7 +60,7 @@ func (s int8Serializer) Write(f *Fury, buf *ByteBuffer, value reflect.Value) err
 }
 
 func (s int8Serializer) Read(f *Fury, buf *ByteBuffer, type_ reflect.Type, value reflect.Value) error {
-	value.Set(reflect.ValueOf(int8(buf.ReadByte_())))
+	value.SetInt(int64(int8(buf.ReadByte_())))
 	return nil
 }
 
@@ -77,7 +78,7 @@ func (s byteSerializer) Write(f *Fury, buf *ByteBuffer, value reflect.Value) err
 
 func (s byteSerializer) Read(f *Fury, buf *ByteBuffer, type_ reflect.Type, value reflect.Value) error {
 	// In java, this will be deserialized to an int value to hold unsigned byte range.
-	value.Set(reflect.ValueOf(buf.ReadByte_()))
+	value.SetUint(uint64(buf.ReadByte_()))
 	return nil
 }
 
@@ -94,7 +95,7 @@ func (s int16Serializer) Write(f *Fury, buf *ByteBuffer, value reflect.Value) er
 }
 
 func (s int16Serializer) Read(f *Fury, buf *ByteBuffer, type_ reflect.Type, value reflect.Value) error {
-	value.Set(reflect.ValueOf(buf.ReadInt16()))
+	value.SetInt(int64(buf.ReadInt16()))
 	return nil
 }
 
@@ -111,7 +112,7 @@ func (s int32Serializer) Write(f *Fury, buf *ByteBuffer, value reflect.Value) er
 }
 
 func (s int32Serializer) Read(f *Fury, buf *ByteBuffer, type_ reflect.Type, value reflect.Value) error {
-	value.Set(reflect.ValueOf(buf.ReadInt32()))
+	value.SetInt(int64(buf.ReadInt32()))
 	return nil
 }
 
@@ -128,7 +129,7 @@ func (s int64Serializer) Write(f *Fury, buf *ByteBuffer, value reflect.Value) er
 }
 
 func (s int64Serializer) Read(f *Fury, buf *ByteBuffer, type_ reflect.Type, value reflect.Value) error {
-	value.Set(reflect.ValueOf(buf.ReadInt64()))
+	value.SetInt(buf.ReadInt64())
 	return nil
 }
 
@@ -149,7 +150,138 @@ func (s intSerializer) Read(f *Fury, buf *ByteBuffer, type_ reflect.Type, value
 	if v > MaxInt || v < MinInt {
 		return fmt.Errorf("int64 %d exceed int range", v)
 	}
-	value.Set(reflect.ValueOf(int(v)))
+	value.SetInt(v)
+	return nil
+}
+
+// varInt32Serializer writes int32 as signed varint32 with type id FURY_VAR_INT32, it's used when the int encoding
+// is INT_ENCODING_VARINT.
+type varInt32Serializer struct {
+}
+
+func (s varInt32Serializer) TypeId() TypeId {
+	return FURY_VAR_INT32
+}
+
+func (s varInt32Serializer) Write(f *Fury, buf *ByteBuffer, value reflect.Value) error {
+	buf.WriteSignedVarInt32(int32(value.Int()))
+	return nil
+}
+
+func (s varInt32Serializer) Read(f *Fury, buf *ByteBuffer, type_ reflect.Type, value reflect.Value) error {
+	value.SetInt(int64(buf.ReadSignedVarInt32()))
+	return nil
+}
+
+type varInt64Serializer struct {
+}
+
+func (s varInt64Serializer) TypeId() TypeId {
+	return FURY_VAR_INT64
+}
+
+func (s varInt64Serializer) Write(f *Fury, buf *ByteBuffer, value reflect.Value) error {
+	buf.WriteSignedVarInt64(value.Int())
+	return nil
+}
+
+func (s varInt64Serializer) Read(f *Fury, buf *ByteBuffer, type_ reflect.Type, value reflect.Value) error {
+	value.SetInt(buf.ReadSignedVarInt64())
+	return nil
+}
+
+type varIntSerializer struct {
+}
+
+func (s varIntSerializer) TypeId() TypeId {
+	return -FURY_VAR_INT64
+}
+
+func (s varIntSerializer) Write(f *Fury, buf *ByteBuffer, value reflect.Value) error {
+	buf.WriteSignedVarInt64(value.Int())
+	return nil
+}
+
+func (s varIntSerializer) Read(f *Fury, buf *ByteBuffer, type_ reflect.Type, value reflect.Value) error {
+	v := buf.ReadSignedVarInt64()
+	if v > MaxInt || v < MinInt {
+		return fmt.Errorf("int64 %d exceed int range", v)
+	}
+	value.SetInt(v)
+	return nil
+}
+
+type uint16Serializer struct {
+}
+
+func (s uint16Serializer) TypeId() TypeId {
+	return UINT16
+}
+
+func (s uint16Serializer) Write(f *Fury, buf *ByteBuffer, value reflect.Value) error {
+	buf.WriteInt16(int16(value.Uint()))
+	return nil
+}
+
+func (s uint16Serializer) Read(f *Fury, buf *ByteBuffer, type_ reflect.Type, value reflect.Value) error {
+	value.SetUint(uint64(uint16(buf.ReadInt16())))
+	return nil
+}
+
+type uint32Serializer struct {
+}
+
+func (s uint32Serializer) TypeId() TypeId {
+	return UINT32
+}
+
+func (s uint32Serializer) Write(f *Fury, buf *ByteBuffer, value reflect.Value) error {
+	buf.WriteInt32(int32(value.Uint()))
+	return nil
+}
+
+func (s uint32Serializer) Read(f *Fury, buf *ByteBuffer, type_ reflect.Type, value reflect.Value) error {
+	value.SetUint(uint64(buf.ReadUint32()))
+	return nil
+}
+
+type uint64Serializer struct {
+}
+
+func (s uint64Serializer) TypeId() TypeId {
+	return UINT64
+}
+
+func (s uint64Serializer) Write(f *Fury, buf *ByteBuffer, value reflect.Value) error {
+	buf.WriteInt64(int64(value.Uint()))
+	return nil
+}
+
+func (s uint64Serializer) Read(f *Fury, buf *ByteBuffer, type_ reflect.Type, value reflect.Value) error {
+	value.SetUint(buf.ReadUint64())
+	return nil
+}
+
+// uintSerializer serializes uint/uintptr as UINT64, the golang type info is written to distinguish them
+// from uint64.
+type uintSerializer struct {
+}
+
+func (s uintSerializer) TypeId() TypeId {
+	return -UINT64
+}
+
+func (s uintSerializer) Write(f *Fury, buf *ByteBuffer, value reflect.Value) error {
+	buf.WriteInt64(int64(value.Uint()))
+	return nil
+}
+
+func (s uintSerializer) Read(f *Fury, buf *ByteBuffer, type_ reflect.Type, value reflect.Value) error {
+	v := buf.ReadUint64()
+	if value.OverflowUint(v) {
+		return fmt.Errorf("uint64 %d exceed %s range", v, value.Type())
+	}
+	value.SetUint(v)
 	return nil
 }
 
@@ -166,7 +298,7 @@ func (s float32Serializer) Write(f *Fury, buf *ByteBuffer, value reflect.Value)
 }
 
 func (s float32Serializer) Read(f *Fury, buf *ByteBuffer, type_ reflect.Type, value reflect.Value) error {
-	value.Set(reflect.ValueOf(buf.ReadFloat32()))
+	value.SetFloat(float64(buf.ReadFloat32()))
 	return nil
 }
 
@@ -183,7 +315,51 @@ func (s float64Serializer) Write(f *Fury, buf *ByteBuffer, value reflect.Value)
 }
 
 func (s float64Serializer) Read(f *Fury, buf *ByteBuffer, type_ reflect.Type, value reflect.Value) error {
-	value.Set(reflect.ValueOf(buf.ReadFloat64()))
+	value.SetFloat(buf.ReadFloat64())
+	return nil
+}
+
+// complex64Serializer and complex128Serializer only work in golang, since complex numbers are not supported
+// by xlang protocol.
+type complex64Serializer struct {
+}
+
+func (s complex64Serializer) TypeId() TypeId {
+	return NotSupportCrossLanguage
+}
+
+func (s complex64Serializer) Write(f *Fury, buf *ByteBuffer, value reflect.Value) error {
+	v := value.Complex()
+	buf.WriteFloat32(float32(real(v)))
+	buf.WriteFloat32(float32(imag(v)))
+	return nil
+}
+
+func (s complex64Serializer) Read(f *Fury, buf *ByteBuffer, type_ reflect.Type, value reflect.Value) error {
+	real_ := buf.ReadFloat32()
+	imag_ := buf.ReadFloat32()
+	value.SetComplex(complex128(complex(real_, imag_)))
+	return nil
+}
+
+type complex128Serializer struct {
+}
+
+func (s complex128Serializer) TypeId() TypeId {
+	return NotSupportCrossLanguage
+}
+
+func (s complex128Serializer) Write(f *Fury, buf *ByteBuffer, value reflect.Value) error {
+	v := value.Complex()
+	buf.WriteFloat64(real(v))
+	buf.WriteFloat64(imag(v))
+	return nil
+}
+
+func (s complex128Serializer) Read(f *Fury, buf *ByteBuffer, type_ reflect.Type, value reflect.Value) error {
+	real_ := buf.ReadFloat64()
+	imag_ := buf.ReadFloat64()
+	value.SetComplex(complex(real_, imag_))
 	return nil
 }
 
@@ -197,12 +373,11 @@ func (s stringSerializer) TypeId() TypeId {
 func (s stringSerializer) Write(f *Fury, buf *ByteBuffer, value reflect.Value) error {
 	// string bytes data reference has been handled in `referenceResolver`.
 	// We handle string reference instead of string data reference for cross-language compatibility.
-	return writeString(buf, value.Interface().(string))
+	return writeString(f, buf, value.String())
 }
 
 func (s stringSerializer) Read(f *Fury, buf *ByteBuffer, type_ reflect.Type, value reflect.Value) error {
-	str := string(buf.ReadBinary(int(buf.ReadVarInt32())))
-	value.Set(reflect.ValueOf(str))
+	value.SetString(readString(f, buf))
 	return nil
 }
 
@@ -218,17 +393,21 @@ func (s ptrToStringSerializer) TypeId() TypeId {
 func (s ptrToStringSerializer) Write(f *Fury, buf *ByteBuffer, value reflect.Value) error {
 	// string reference has been handled in `referenceResolver` by ptr type.
 	// We handle string reference instead of string data reference for cross-language compatibility.
-	return writeString(buf, value.Elem().Interface().(string))
+	return writeString(f, buf, value.Elem().String())
 }
 
 func (s ptrToStringSerializer) Read(f *Fury, buf *ByteBuffer, type_ reflect.Type, value reflect.Value) error {
-	str := string(readStringBytes(buf))
+	str := readString(f, buf)
 	value.Set(reflect.ValueOf(&str))
 	return nil
 }
 
-func writeString(buf *ByteBuffer, value string) error {
+// writeString writes `value` in the string encoding of config, prefixed by the length of the encoded bytes.
+func writeString(f *Fury, buf *ByteBuffer, value string) error {
 	strBytes := unsafeGetBytes(value)
+	if f.config.stringEncoding == STRING_ENCODING_UTF16 {
+		strBytes = encodeUTF16(value)
+	}
 	if len(strBytes) >= MaxInt32 {
 		return fmt.Errorf("too long string: %d", len(strBytes))
 	}
@@ -237,12 +416,35 @@ func writeString(buf *ByteBuffer, value string) error {
 	return nil
 }
 
-func readString(buf *ByteBuffer) string {
-	return string(readStringBytes(buf))
+func readString(f *Fury, buf *ByteBuffer) string {
+	length := f.readBinaryLength(buf)
+	f.allocate(buf, length)
+	if f.config.stringEncoding == STRING_ENCODING_UTF16 {
+		if length%2 != 0 {
+			panic(invalidDataError(buf, "odd length %d of utf16 string", length))
+		}
+		return decodeUTF16(buf.ReadBinary(length))
+	}
+	return string(buf.ReadBinary(length))
+}
+
+// encodeUTF16 returns the UTF-16 bytes of `value` in little endian order.
+func encodeUTF16(value string) []byte {
+	units := utf16.Encode([]rune(value))
+	data := make([]byte, 2*len(units))
+	for i, unit := range units {
+		binary.LittleEndian.PutUint16(data[2*i:], unit)
+	}
+	return data
 }
 
-func readStringBytes(buf *ByteBuffer) []byte {
-	return buf.ReadBinary(int(buf.ReadVarInt32()))
+// decodeUTF16 returns the string of UTF-16 bytes in little endian order, invalid surrogates are replaced by U+FFFD.
+func decodeUTF16(data []byte) string {
+	units := make([]uint16, len(data)/2)
+	for i := range units {
+		units[i] = binary.LittleEndian.Uint16(data[2*i:])
+	}
+	return string(utf16.Decode(units))
 }
 
 type arraySerializer struct {
@@ -265,6 +467,9 @@ func (s arraySerializer) Write(f *Fury, buf *ByteBuffer, value reflect.Value) er
 }
 func (s arraySerializer) Read(f *Fury, buf *ByteBuffer, type_ reflect.Type, value reflect.Value) error {
 	length := f.readLength(buf)
+	if length != value.Len() {
+		return invalidDataError(buf, "%s has len %d, but got %d elements", value.Type(), value.Len(), length)
+	}
 	for i := 0; i < length; i++ {
 		elem := value.Index(i)
 		if err := f.ReadReferencable(buf, elem); err != nil {
@@ -300,6 +505,9 @@ func (s *arrayConcreteValueSerializer) Write(f *Fury, buf *ByteBuffer, value ref
 
 func (s *arrayConcreteValueSerializer) Read(f *Fury, buf *ByteBuffer, type_ reflect.Type, value reflect.Value) error {
 	length := buf.ReadLength()
+	if length != value.Len() {
+		return invalidDataError(buf, "%s has len %d, but got %d elements", value.Type(), value.Len(), length)
+	}
 	for i := 0; i < length; i++ {
 		if err := readBySerializer(f, buf, value.Index(i), s.elemSerializer, s.referencable); err != nil {
 			return err
@@ -328,64 +536,18 @@ func (s byteArraySerializer) Write(f *Fury, buf *ByteBuffer, value reflect.Value
 }
 
 func (s byteArraySerializer) Read(f *Fury, buf *ByteBuffer, type_ reflect.Type, value reflect.Value) error {
-	length := buf.ReadInt32()
-	if int(length) != value.Len() {
-		return fmt.Errorf("%s has len %d, but fury has len elements %d", value.Type(), value.Len(), length)
+	length := f.readLength(buf)
+	if length != value.Len() {
+		return invalidDataError(buf, "%s has len %d, but got %d elements", value.Type(), value.Len(), length)
 	}
-	_, err := buf.Read(value.Slice(0, int(length)).Bytes())
-	return err
+	reflect.Copy(value, reflect.ValueOf(buf.ReadBinary(length)))
+	return nil
 }
 
 func (s byteArraySerializer) TypeId() TypeId {
 	return -BINARY
 }
 
-// Date represents an imprecise date.
-type Date struct {
-	Year  int        // Year. E.g., 2009.
-	Month time.Month // Month is 1 - 12. 0 means unspecified.
-	Day   int
-}
-
-type dateSerializer struct {
-}
-
-func (s dateSerializer) TypeId() TypeId {
-	return DATE32
-}
-
-func (s dateSerializer) Write(f *Fury, buf *ByteBuffer, value reflect.Value) error {
-	date := value.Interface().(Date)
-	diff := time.Date(date.Year, date.Month, date.Day, 0, 0, 0, 0, time.Local).Sub(
-		time.Date(1970, 1, 1, 0, 0, 0, 0, time.Local))
-	buf.WriteInt32(int32(diff.Hours() / 24))
-	return nil
-}
-
-func (s dateSerializer) Read(f *Fury, buf *ByteBuffer, type_ reflect.Type, value reflect.Value) error {
-	diff := time.Duration(buf.ReadInt32()) * 24 * time.Hour
-	date := time.Date(1970, 1, 1, 0, 0, 0, 0, time.Local).Add(diff)
-	value.Set(reflect.ValueOf(Date{date.Year(), date.Month(), date.Day()}))
-	return nil
-}
-
-type timeSerializer struct {
-}
-
-func (s timeSerializer) TypeId() TypeId {
-	return TIMESTAMP
-}
-
-func (s timeSerializer) Write(f *Fury, buf *ByteBuffer, value reflect.Value) error {
-	buf.WriteInt64(GetUnixMicro(value.Interface().(time.Time)))
-	return nil
-}
-
-func (s timeSerializer) Read(f *Fury, buf *ByteBuffer, type_ reflect.Type, value reflect.Value) error {
-	value.Set(reflect.ValueOf(CreateTimeFromUnixMicro(buf.ReadInt64())))
-	return nil
-}
-
 // ptrToValueSerializer serialize a ptr which point to a concrete value.
 // Pointer to interface are not allowed in fury.
 type ptrToValueSerializer struct {
@@ -405,11 +567,34 @@ func (s *ptrToValueSerializer) Write(f *Fury, buf *ByteBuffer, value reflect.Val
 }
 
 func (s *ptrToValueSerializer) Read(f *Fury, buf *ByteBuffer, type_ reflect.Type, value reflect.Value) error {
+	f.allocate(buf, int(type_.Elem().Size()))
 	newValue := reflect.New(type_.Elem())
 	value.Set(newValue)
 	return s.valueSerializer.Read(f, buf, type_.Elem(), newValue.Elem())
 }
 
+// namedTypeSerializer serializes a named type whose underlying type is a basic type, such as `type MyInt int32`.
+// The type id is negative so that the golang type info will be written.
+type namedTypeSerializer struct {
+	basicSerializer Serializer
+}
+
+func (s *namedTypeSerializer) TypeId() TypeId {
+	if id := s.basicSerializer.TypeId(); id < 0 {
+		return id
+	} else {
+		return -id
+	}
+}
+
+func (s *namedTypeSerializer) Write(f *Fury, buf *ByteBuffer, value reflect.Value) error {
+	return s.basicSerializer.Write(f, buf, value)
+}
+
+func (s *namedTypeSerializer) Read(f *Fury, buf *ByteBuffer, type_ reflect.Type, value reflect.Value) error {
+	return s.basicSerializer.Read(f, buf, type_, value)
+}
+
 func writeBySerializer(f *Fury, buf *ByteBuffer, value reflect.Value, serializer Serializer, referencable bool) error {
 	if referencable {
 		return f.writeReferencableBySerializer(buf, value, serializer)
@@ -431,10 +616,58 @@ func readBySerializer(f *Fury, buf *ByteBuffer, value reflect.Value, serializer
 	}
 }
 
-// TODO(chaokunyang) support custom serialization
-
+// Marshaller is implemented by types which control their own encoding, such as money or geometry types. Values of
+// those types are written with the EXTENSION type id followed by the ext id, which identifies the type across
+// languages, and the data written by MarshalFury. UnmarshalFury is invoked on a pointer to a zero value.
 type Marshaller interface {
+	// ExtId returns the id of the type, which must be the same for all values of the type.
 	ExtId() int16
 	MarshalFury(f *Fury, buf *ByteBuffer) error
 	UnmarshalFury(f *Fury, buf *ByteBuffer) error
 }
+
+var marshallerType = reflect.TypeOf((*Marshaller)(nil)).Elem()
+
+// isMarshaller returns whether values of `type_` are serialized by Marshaller. Values of non-pointer types are
+// marshalled by their pointers, since UnmarshalFury needs a pointer receiver.
+func isMarshaller(type_ reflect.Type) bool {
+	if type_.Kind() == reflect.Ptr {
+		return type_.Implements(marshallerType)
+	}
+	return type_.Kind() != reflect.Interface && reflect.PtrTo(type_).Implements(marshallerType)
+}
+
+// marshallerSerializer serializes values of a Marshaller type or pointers to them.
+type marshallerSerializer struct {
+	extId int16
+	isPtr bool
+}
+
+func (s *marshallerSerializer) TypeId() TypeId {
+	return EXTENSION
+}
+
+func (s *marshallerSerializer) Write(f *Fury, buf *ByteBuffer, value reflect.Value) error {
+	if !s.isPtr {
+		if !value.CanAddr() {
+			ptr := reflect.New(value.Type())
+			ptr.Elem().Set(value)
+			value = ptr.Elem()
+		}
+		value = value.Addr()
+	}
+	return value.Interface().(Marshaller).MarshalFury(f, buf)
+}
+
+func (s *marshallerSerializer) Read(f *Fury, buf *ByteBuffer, type_ reflect.Type, value reflect.Value) error {
+	if !s.isPtr {
+		return value.Addr().Interface().(Marshaller).UnmarshalFury(f, buf)
+	}
+	f.allocate(buf, int(type_.Elem().Size()))
+	newValue := reflect.New(type_.Elem())
+	if err := newValue.Interface().(Marshaller).UnmarshalFury(f, buf); err != nil {
+		return err
+	}
+	value.Set(newValue)
+	return nil
+}
@@ -17,10 +17,14 @@
 
 package fury
 
-import "reflect"
+import (
+	"reflect"
+)
 
-// GenericSet type.
-// TODO use golang generics; support more concrete key types
+// GenericSet is a set of values of any types, which is read from sets of peers when the elements type can't be
+// known. Sets of concrete types such as `map[string]struct{}` and `Set[T]` are serialized by the serializer of their
+// element type, so are `map[T]bool` fields with the `set` option in fury tag. Other `map[T]bool` values are
+// serialized as maps, since their false values would be lost in a set.
 type GenericSet map[interface{}]bool
 
 func (s GenericSet) Add(values ...interface{}) {
@@ -29,6 +33,37 @@ func (s GenericSet) Add(values ...interface{}) {
 	}
 }
 
+// Set is a set of values of type T, which is serialized as FURY_SET like GenericSet. Its serializer is created
+// once for every type parameter, so the values are written by the serializer of T without looking it up.
+type Set[T comparable] map[T]struct{}
+
+// NewSet returns a set of `values`.
+func NewSet[T comparable](values ...T) Set[T] {
+	s := make(Set[T], len(values))
+	s.Add(values...)
+	return s
+}
+
+func (s Set[T]) Add(values ...T) {
+	for _, v := range values {
+		s[v] = struct{}{}
+	}
+}
+
+func (s Set[T]) Contains(value T) bool {
+	_, ok := s[value]
+	return ok
+}
+
+func (s Set[T]) newSerializer(r *typeResolver) (Serializer, error) {
+	elemSerializer, referencable, err := r.getElemSerializer(reflect.TypeOf((*T)(nil)).Elem())
+	if err != nil {
+		return nil, err
+	}
+	return &typedSetSerializer[T]{&setConcreteValueSerializer{elemSerializer: elemSerializer, referencable: referencable}},
+		nil
+}
+
 type setSerializer struct {
 }
 
@@ -49,19 +84,125 @@ func (s setSerializer) Write(f *Fury, buf *ByteBuffer, value reflect.Value) erro
 	return nil
 }
 
+// Read reads a set into a GenericSet, or another set type such as Set[T] when the data is written by peers.
 func (s setSerializer) Read(f *Fury, buf *ByteBuffer, type_ reflect.Type, value reflect.Value) error {
+	if value.Type() != genericSetType {
+		return readSet(f, buf, value)
+	}
 	if value.IsNil() {
 		value.Set(reflect.ValueOf(GenericSet{}))
 	}
 	f.refResolver.Reference(value)
 	genericSet := value.Interface().(GenericSet)
 	length := f.readLength(buf)
+	f.allocate(buf, length*int(reflect.TypeOf(genericSet).Key().Size()))
 	for i := 0; i < length; i++ {
 		var mapKey interface{}
 		if err := f.ReadReferencable(buf, reflect.ValueOf(&mapKey).Elem()); err != nil {
 			return err
 		}
+		if !isHashable(reflect.ValueOf(&mapKey).Elem()) {
+			return invalidDataError(buf, "set element of type %T isn't hashable", mapKey)
+		}
 		genericSet[mapKey] = true
 	}
 	return nil
 }
+
+// readSet reads the elements of a set written by peers into the keys of map `value`.
+func readSet(f *Fury, buf *ByteBuffer, value reflect.Value) error {
+	return readSetBySerializer(f, buf, value, nil, true)
+}
+
+// readSetBySerializer reads the elements of a set into the keys of map `value`, whose values are set to true for
+// `map[T]bool` or the zero value such as `struct{}{}` otherwise.
+func readSetBySerializer(f *Fury, buf *ByteBuffer, value reflect.Value, serializer Serializer,
+	referencable bool) error {
+	type_ := value.Type()
+	length := f.readLength(buf)
+	f.allocate(buf, length*int(type_.Key().Size()))
+	if value.IsNil() {
+		value.Set(reflect.MakeMapWithSize(type_, length))
+	}
+	f.refResolver.Reference(value)
+	present := reflect.Zero(type_.Elem())
+	if type_.Elem().Kind() == reflect.Bool {
+		present = reflect.ValueOf(true).Convert(type_.Elem())
+	}
+	for i := 0; i < length; i++ {
+		key := reflect.New(type_.Key()).Elem()
+		if err := readBySerializer(f, buf, key, serializer, referencable); err != nil {
+			return err
+		}
+		if !isHashable(key) {
+			return invalidDataError(buf, "set element %v isn't hashable", key)
+		}
+		value.SetMapIndex(key, present)
+	}
+	return nil
+}
+
+// isSetType returns whether `type_` is a set of concrete elements, which is a map of `struct{}` values.
+func isSetType(type_ reflect.Type) bool {
+	if type_.Kind() != reflect.Map || isDynamicType(type_.Key()) {
+		return false
+	}
+	elem := type_.Elem()
+	return elem.Kind() == reflect.Struct && elem.NumField() == 0
+}
+
+// newSetConcreteValueSerializer creates the serializer of a set of concrete elements, which is a map of `struct{}`
+// values, or a `map[T]bool` field with the `set` option in fury tag. Keys whose values are false in a `map[T]bool`
+// are absent from the set, so they aren't serialized.
+func newSetConcreteValueSerializer(r *typeResolver, type_ reflect.Type) (*setConcreteValueSerializer, error) {
+	elemSerializer, err := r.getSerializerByType(type_.Key())
+	if err != nil {
+		return nil, err
+	}
+	return &setConcreteValueSerializer{elemSerializer: elemSerializer, referencable: nullable(type_.Key())}, nil
+}
+
+// setConcreteValueSerializer serializes a set of concrete elements, see newSetConcreteValueSerializer.
+type setConcreteValueSerializer struct {
+	elemSerializer Serializer
+	referencable   bool
+}
+
+func (s *setConcreteValueSerializer) TypeId() TypeId {
+	return -FURY_SET
+}
+
+func (s *setConcreteValueSerializer) Write(f *Fury, buf *ByteBuffer, value reflect.Value) error {
+	isBool := value.Type().Elem().Kind() == reflect.Bool
+	length := value.Len()
+	if isBool {
+		iter := value.MapRange()
+		for iter.Next() {
+			if !iter.Value().Bool() {
+				length--
+			}
+		}
+	}
+	if err := f.writeLength(buf, length); err != nil {
+		return err
+	}
+	iter := value.MapRange()
+	for iter.Next() {
+		if isBool && !iter.Value().Bool() {
+			continue
+		}
+		if err := writeBySerializer(f, buf, iter.Key(), s.elemSerializer, s.referencable); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *setConcreteValueSerializer) Read(f *Fury, buf *ByteBuffer, type_ reflect.Type, value reflect.Value) error {
+	return readSetBySerializer(f, buf, value, s.elemSerializer, s.referencable)
+}
+
+// typedSetSerializer serializes Set[T] by the serializer of T, which is a setConcreteValueSerializer created for T.
+type typedSetSerializer[T comparable] struct {
+	*setConcreteValueSerializer
+}
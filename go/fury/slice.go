@@ -44,6 +44,7 @@ func (s sliceSerializer) Write(f *Fury, buf *ByteBuffer, value reflect.Value) er
 func (s sliceSerializer) Read(f *Fury, buf *ByteBuffer, type_ reflect.Type, value reflect.Value) error {
 	length := f.readLength(buf)
 	if value.Cap() < length {
+		f.allocate(buf, length*int(value.Type().Elem().Size()))
 		value.Set(reflect.MakeSlice(value.Type(), length, length))
 	} else if value.Len() < length {
 		value.Set(value.Slice(0, length))
@@ -84,9 +85,10 @@ func (s *sliceConcreteValueSerializer) Write(f *Fury, buf *ByteBuffer, value ref
 
 func (s *sliceConcreteValueSerializer) Read(f *Fury, buf *ByteBuffer, type_ reflect.Type, value reflect.Value) error {
 	length := f.readLength(buf)
-	if value.Cap() < length {
+	if value.IsNil() || value.Cap() < length {
+		f.allocate(buf, length*int(value.Type().Elem().Size()))
 		value.Set(reflect.MakeSlice(value.Type(), length, length))
-	} else if value.Len() < length {
+	} else if value.Len() != length {
 		value.Set(value.Slice(0, length))
 	}
 	f.refResolver.Reference(value)
@@ -98,6 +100,28 @@ func (s *sliceConcreteValueSerializer) Read(f *Fury, buf *ByteBuffer, type_ refl
 	return nil
 }
 
+// List is a slice of values of type T, which is serialized as LIST. Its serializer is created once for every
+// type parameter, so the elements are written by the serializer of T without looking it up.
+type List[T any] []T
+
+func (l List[T]) newSerializer(r *typeResolver) (Serializer, error) {
+	elemSerializer, referencable, err := r.getElemSerializer(reflect.TypeOf((*T)(nil)).Elem())
+	if err != nil {
+		return nil, err
+	}
+	return &typedListSerializer[T]{&sliceConcreteValueSerializer{
+		type_:          reflect.TypeOf(l),
+		elemSerializer: elemSerializer,
+		referencable:   referencable,
+	}}, nil
+}
+
+// typedListSerializer serializes List[T] by the serializer of T, which is a sliceConcreteValueSerializer created
+// for T.
+type typedListSerializer[T any] struct {
+	*sliceConcreteValueSerializer
+}
+
 type byteSliceSerializer struct {
 }
 
@@ -158,7 +182,7 @@ func (s boolSliceSerializer) Write(f *Fury, buf *ByteBuffer, value reflect.Value
 }
 
 func (s boolSliceSerializer) Read(f *Fury, buf *ByteBuffer, type_ reflect.Type, value reflect.Value) error {
-	length := buf.ReadLength()
+	length := f.readPrimitiveArrayLength(buf, 1)
 	r := make([]bool, length, length)
 	for i := 0; i < length; i++ {
 		r[i] = buf.ReadBool()
@@ -188,7 +212,7 @@ func (s int16SliceSerializer) Write(f *Fury, buf *ByteBuffer, value reflect.Valu
 }
 
 func (s int16SliceSerializer) Read(f *Fury, buf *ByteBuffer, type_ reflect.Type, value reflect.Value) error {
-	length := buf.ReadLength() / 2
+	length := f.readPrimitiveArrayLength(buf, 2)
 	r := make([]int16, length, length)
 	for i := 0; i < length; i++ {
 		r[i] = buf.ReadInt16()
@@ -218,7 +242,7 @@ func (s int32SliceSerializer) Write(f *Fury, buf *ByteBuffer, value reflect.Valu
 }
 
 func (s int32SliceSerializer) Read(f *Fury, buf *ByteBuffer, type_ reflect.Type, value reflect.Value) error {
-	length := buf.ReadLength() / 4
+	length := f.readPrimitiveArrayLength(buf, 4)
 	r := make([]int32, length, length)
 	for i := 0; i < length; i++ {
 		r[i] = buf.ReadInt32()
@@ -248,7 +272,7 @@ func (s int64SliceSerializer) Write(f *Fury, buf *ByteBuffer, value reflect.Valu
 }
 
 func (s int64SliceSerializer) Read(f *Fury, buf *ByteBuffer, type_ reflect.Type, value reflect.Value) error {
-	length := buf.ReadLength() / 8
+	length := f.readPrimitiveArrayLength(buf, 8)
 	r := make([]int64, length, length)
 	for i := 0; i < length; i++ {
 		r[i] = buf.ReadInt64()
@@ -278,7 +302,7 @@ func (s float32SliceSerializer) Write(f *Fury, buf *ByteBuffer, value reflect.Va
 }
 
 func (s float32SliceSerializer) Read(f *Fury, buf *ByteBuffer, type_ reflect.Type, value reflect.Value) error {
-	length := buf.ReadLength() / 4
+	length := f.readPrimitiveArrayLength(buf, 4)
 	r := make([]float32, length, length)
 	for i := 0; i < length; i++ {
 		r[i] = buf.ReadFloat32()
@@ -308,7 +332,7 @@ func (s float64SliceSerializer) Write(f *Fury, buf *ByteBuffer, value reflect.Va
 }
 
 func (s float64SliceSerializer) Read(f *Fury, buf *ByteBuffer, type_ reflect.Type, value reflect.Value) error {
-	length := buf.ReadLength() / 8
+	length := f.readPrimitiveArrayLength(buf, 8)
 	r := make([]float64, length, length)
 	for i := 0; i < length; i++ {
 		r[i] = buf.ReadFloat64()
@@ -317,6 +341,132 @@ func (s float64SliceSerializer) Read(f *Fury, buf *ByteBuffer, type_ reflect.Typ
 	return nil
 }
 
+// uint16SliceSerializer and other unsigned slice serializers write the same data as the signed primitive
+// arrays of the same size, the golang type info is written to distinguish them from signed arrays.
+type uint16SliceSerializer struct {
+}
+
+func (s uint16SliceSerializer) TypeId() TypeId {
+	return -FURY_PRIMITIVE_SHORT_ARRAY
+}
+
+func (s uint16SliceSerializer) Write(f *Fury, buf *ByteBuffer, value reflect.Value) error {
+	v := value.Interface().([]uint16)
+	size := len(v) * 2
+	if size >= MaxInt32 {
+		return fmt.Errorf("too long slice: %d", len(v))
+	}
+	buf.WriteLength(size)
+	for _, elem := range v {
+		buf.WriteInt16(int16(elem))
+	}
+	return nil
+}
+
+func (s uint16SliceSerializer) Read(f *Fury, buf *ByteBuffer, type_ reflect.Type, value reflect.Value) error {
+	length := f.readPrimitiveArrayLength(buf, 2)
+	r := make([]uint16, length, length)
+	for i := 0; i < length; i++ {
+		r[i] = uint16(buf.ReadInt16())
+	}
+	value.Set(reflect.ValueOf(r))
+	return nil
+}
+
+type uint32SliceSerializer struct {
+}
+
+func (s uint32SliceSerializer) TypeId() TypeId {
+	return -FURY_PRIMITIVE_INT_ARRAY
+}
+
+func (s uint32SliceSerializer) Write(f *Fury, buf *ByteBuffer, value reflect.Value) error {
+	v := value.Interface().([]uint32)
+	size := len(v) * 4
+	if size >= MaxInt32 {
+		return fmt.Errorf("too long slice: %d", len(v))
+	}
+	buf.WriteLength(size)
+	for _, elem := range v {
+		buf.WriteInt32(int32(elem))
+	}
+	return nil
+}
+
+func (s uint32SliceSerializer) Read(f *Fury, buf *ByteBuffer, type_ reflect.Type, value reflect.Value) error {
+	length := f.readPrimitiveArrayLength(buf, 4)
+	r := make([]uint32, length, length)
+	for i := 0; i < length; i++ {
+		r[i] = buf.ReadUint32()
+	}
+	value.Set(reflect.ValueOf(r))
+	return nil
+}
+
+type uint64SliceSerializer struct {
+}
+
+func (s uint64SliceSerializer) TypeId() TypeId {
+	return -FURY_PRIMITIVE_LONG_ARRAY
+}
+
+func (s uint64SliceSerializer) Write(f *Fury, buf *ByteBuffer, value reflect.Value) error {
+	v := value.Interface().([]uint64)
+	size := len(v) * 8
+	if size >= MaxInt32 {
+		return fmt.Errorf("too long slice: %d", len(v))
+	}
+	buf.WriteLength(size)
+	for _, elem := range v {
+		buf.WriteInt64(int64(elem))
+	}
+	return nil
+}
+
+func (s uint64SliceSerializer) Read(f *Fury, buf *ByteBuffer, type_ reflect.Type, value reflect.Value) error {
+	length := f.readPrimitiveArrayLength(buf, 8)
+	r := make([]uint64, length, length)
+	for i := 0; i < length; i++ {
+		r[i] = buf.ReadUint64()
+	}
+	value.Set(reflect.ValueOf(r))
+	return nil
+}
+
+type uintSliceSerializer struct {
+}
+
+func (s uintSliceSerializer) TypeId() TypeId {
+	return -FURY_PRIMITIVE_LONG_ARRAY
+}
+
+func (s uintSliceSerializer) Write(f *Fury, buf *ByteBuffer, value reflect.Value) error {
+	v := value.Interface().([]uint)
+	size := len(v) * 8
+	if size >= MaxInt32 {
+		return fmt.Errorf("too long slice: %d", len(v))
+	}
+	buf.WriteLength(size)
+	for _, elem := range v {
+		buf.WriteInt64(int64(elem))
+	}
+	return nil
+}
+
+func (s uintSliceSerializer) Read(f *Fury, buf *ByteBuffer, type_ reflect.Type, value reflect.Value) error {
+	length := f.readPrimitiveArrayLength(buf, 8)
+	r := make([]uint, length, length)
+	for i := 0; i < length; i++ {
+		v := buf.ReadUint64()
+		if uint64(uint(v)) != v {
+			return fmt.Errorf("uint64 %d exceed uint range", v)
+		}
+		r[i] = uint(v)
+	}
+	value.Set(reflect.ValueOf(r))
+	return nil
+}
+
 type stringSliceSerializer struct {
 	strSerializer stringSerializer
 }
@@ -334,7 +484,7 @@ func (s stringSliceSerializer) Write(f *Fury, buf *ByteBuffer, value reflect.Val
 	for _, str := range v {
 		if refWritten, err := f.refResolver.WriteRefOrNull(buf, reflect.ValueOf(str)); err == nil {
 			if !refWritten {
-				if err := writeString(buf, str); err != nil {
+				if err := writeString(f, buf, str); err != nil {
 					return err
 				}
 			}
@@ -347,6 +497,7 @@ func (s stringSliceSerializer) Write(f *Fury, buf *ByteBuffer, value reflect.Val
 
 func (s stringSliceSerializer) Read(f *Fury, buf *ByteBuffer, type_ reflect.Type, value reflect.Value) (err error) {
 	length := f.readLength(buf)
+	f.allocate(buf, length*int(reflect.TypeOf("").Size()))
 	r := make([]string, length, length)
 	f.refResolver.Reference(reflect.ValueOf(r))
 	for i := 0; i < length; i++ {
@@ -358,7 +509,7 @@ func (s stringSliceSerializer) Read(f *Fury, buf *ByteBuffer, type_ reflect.Type
 					return err
 				}
 			}
-			elem := readString(buf)
+			elem := readString(f, buf)
 			if f.referenceTracking && refFlag == RefValueFlag {
 				// If value is not nil(reflect), then value is a pointer to some variable, we can update the `value`,
 				// then record `value` in the reference resolver.
@@ -367,14 +518,27 @@ func (s stringSliceSerializer) Read(f *Fury, buf *ByteBuffer, type_ reflect.Type
 			r[i] = elem
 		} else if refFlag == NullFlag {
 			r[i] = ""
+		} else if object := f.refResolver.GetCurrentReadObject(); object.Kind() == reflect.String {
+			r[i] = object.String()
 		} else {
-			r[i] = f.refResolver.GetCurrentReadObject().Interface().(string)
+			return invalidDataError(buf, "invalid string reference")
 		}
 	}
 	value.Set(reflect.ValueOf(r))
 	return nil
 }
 
+// readPrimitiveArrayLength reads the number of elements of a primitive array, which is written as the size
+// in bytes.
+func (f *Fury) readPrimitiveArrayLength(buf *ByteBuffer, elemSize int) int {
+	length := buf.ReadLength() / elemSize
+	if max := f.config.maxCollectionSize; max > 0 && length > max {
+		panic(&LimitError{Offset: buf.readerIndex, Limit: "collection size", Value: length, Max: max})
+	}
+	f.allocate(buf, length*elemSize)
+	return length
+}
+
 // those types will be serialized by `sliceConcreteValueSerializer`, which correspond to List types in java/python
 
 type Int8Slice []int8
@@ -0,0 +1,132 @@
+// Licensed to the Apache Software Foundation (ASF) under one
+// or more contributor license agreements.  See the NOTICE file
+// distributed with this work for additional information
+// regarding copyright ownership.  The ASF licenses this file
+// to you under the Apache License, Version 2.0 (the
+// "License"); you may not use this file except in compliance
+// with the License.  You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package fury
+
+import (
+	"bufio"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+)
+
+// DefaultMaxMessageSize is the default max size of a message read by Decoder.
+const DefaultMaxMessageSize = 64 << 20
+
+// Encoder writes a stream of fury messages to an io.Writer. Every message is framed by its length encoded as
+// an unsigned varint, which is the same encoding as `ByteBuffer.WriteVarInt32` for non-negative values.
+// An Encoder isn't safe for concurrent use.
+type Encoder struct {
+	fury *Fury
+	w    io.Writer
+	buf  []byte
+}
+
+// NewEncoder returns an Encoder writing to w, which uses a new Fury created by `opts`. Use `Fury.NewEncoder`
+// if types need to be registered.
+func NewEncoder(w io.Writer, opts ...Option) *Encoder {
+	return NewFury(opts...).NewEncoder(w)
+}
+
+// NewEncoder returns an Encoder writing to w by this Fury. The Fury shouldn't be used by others when
+// the Encoder is in use.
+func (f *Fury) NewEncoder(w io.Writer) *Encoder {
+	return &Encoder{fury: f, w: w, buf: make([]byte, binary.MaxVarintLen64, 256)}
+}
+
+// Encode writes the fury encoding of v as a message to the stream.
+func (e *Encoder) Encode(v interface{}) error {
+	// reserve the bytes for length header, which is known after serialization.
+	data, err := e.fury.MarshalAppend(e.buf[:binary.MaxVarintLen64], v)
+	if err != nil {
+		return err
+	}
+	e.buf = data
+	var header [binary.MaxVarintLen64]byte
+	n := binary.PutUvarint(header[:], uint64(len(data)-binary.MaxVarintLen64))
+	start := binary.MaxVarintLen64 - n
+	copy(data[start:], header[:n])
+	_, err = e.w.Write(data[start:])
+	return err
+}
+
+// Decoder reads a stream of fury messages written by Encoder from an io.Reader. The reader is buffered, so
+// the Decoder may read data beyond the messages it decoded. A Decoder isn't safe for concurrent use.
+type Decoder struct {
+	fury           *Fury
+	r              *bufio.Reader
+	maxMessageSize int
+	// err is the error of a message which can't be skipped, it's returned by all later calls.
+	err error
+}
+
+// NewDecoder returns a Decoder reading from r, which uses a new Fury created by `opts`. Use `Fury.NewDecoder`
+// if types need to be registered.
+func NewDecoder(r io.Reader, opts ...Option) *Decoder {
+	return NewFury(opts...).NewDecoder(r)
+}
+
+// NewDecoder returns a Decoder reading from r by this Fury. The Fury shouldn't be used by others when
+// the Decoder is in use.
+func (f *Fury) NewDecoder(r io.Reader) *Decoder {
+	return &Decoder{fury: f, r: bufio.NewReader(r), maxMessageSize: DefaultMaxMessageSize}
+}
+
+// SetMaxMessageSize sets the max size of a message, a larger message is reported as an error instead of
+// being read into memory. Default is DefaultMaxMessageSize.
+func (d *Decoder) SetMaxMessageSize(size int) {
+	d.maxMessageSize = size
+}
+
+// Decode reads the next message from the stream and stores the result in the value pointed to by v.
+// io.EOF is returned if the stream ends at a message boundary, and io.ErrUnexpectedEOF is returned if
+// the stream ends in the middle of a message. If the message can't be deserialized or exceeds the max size,
+// the error is returned and the stream is still positioned at the next message.
+func (d *Decoder) Decode(v interface{}) error {
+	if d.err != nil {
+		return d.err
+	}
+	size, err := binary.ReadUvarint(d.r)
+	if err != nil {
+		return err
+	}
+	if size > uint64(d.maxMessageSize) {
+		err := fmt.Errorf("message size %d exceeds max size %d", size, d.maxMessageSize)
+		if size > math.MaxInt64 {
+			d.err = err
+			return err
+		}
+		// skip the message without reading it into memory.
+		if _, discardErr := io.CopyN(io.Discard, d.r, int64(size)); discardErr != nil {
+			if discardErr == io.EOF {
+				discardErr = io.ErrUnexpectedEOF
+			}
+			return discardErr
+		}
+		return err
+	}
+	// allocate a new slice for every message since deserialized values such as []byte may reference it.
+	data := make([]byte, size)
+	if _, err := io.ReadFull(d.r, data); err != nil {
+		if err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+		return err
+	}
+	return d.fury.Unmarshal(data, v)
+}
@@ -0,0 +1,109 @@
+// Licensed to the Apache Software Foundation (ASF) under one
+// or more contributor license agreements.  See the NOTICE file
+// distributed with this work for additional information
+// regarding copyright ownership.  The ASF licenses this file
+// to you under the Apache License, Version 2.0 (the
+// "License"); you may not use this file except in compliance
+// with the License.  You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package fury
+
+import (
+	"bytes"
+	"encoding/binary"
+	"github.com/stretchr/testify/require"
+	"io"
+	"math"
+	"testing"
+)
+
+func TestEncoderDecoder(t *testing.T) {
+	var stream bytes.Buffer
+	writer := NewFury(WithRefTracking(true))
+	require.Nil(t, writer.RegisterTagType("example.Bar", Bar{}))
+	require.Nil(t, writer.RegisterTagType("example.Foo", Foo{}))
+	encoder := writer.NewEncoder(&stream)
+	foo := newFoo()
+	values := []interface{}{"str", int64(-1), []byte{1, 2, 3}, &foo, commonMap(), make([]byte, 1000)}
+	for _, value := range values {
+		require.Nil(t, encoder.Encode(value))
+	}
+
+	reader := NewFury(WithRefTracking(true))
+	require.Nil(t, reader.RegisterTagType("example.Bar", Bar{}))
+	require.Nil(t, reader.RegisterTagType("example.Foo", Foo{}))
+	decoder := reader.NewDecoder(&stream)
+	var newValues []interface{}
+	for {
+		var newValue interface{}
+		err := decoder.Decode(&newValue)
+		if err == io.EOF {
+			break
+		}
+		require.Nil(t, err)
+		newValues = append(newValues, newValue)
+	}
+	require.Equal(t, len(values), len(newValues))
+	require.Equal(t, &foo, newValues[3])
+	newValues[3] = &foo
+	require.Equal(t, values, newValues)
+}
+
+func TestDecoderErrors(t *testing.T) {
+	var stream bytes.Buffer
+	writer := NewFury()
+	require.Nil(t, writer.RegisterTagType("example.Bar", Bar{}))
+	encoder := writer.NewEncoder(&stream)
+	require.Nil(t, encoder.Encode("str1"))
+	require.Nil(t, encoder.Encode(&Bar{}))
+	require.Nil(t, encoder.Encode("str2"))
+	data := stream.Bytes()
+
+	// a message which fails to deserialize doesn't break later messages.
+	decoder := NewDecoder(bytes.NewReader(data))
+	var str string
+	require.Nil(t, decoder.Decode(&str))
+	require.Equal(t, "str1", str)
+	var bar interface{}
+	require.Error(t, decoder.Decode(&bar))
+	require.Nil(t, decoder.Decode(&str))
+	require.Equal(t, "str2", str)
+	require.Equal(t, io.EOF, decoder.Decode(&str))
+
+	decoder = NewDecoder(bytes.NewReader(data[:len(data)-1]))
+	require.Nil(t, decoder.Decode(&str))
+	require.Error(t, decoder.Decode(&bar))
+	require.Equal(t, io.ErrUnexpectedEOF, decoder.Decode(&str))
+
+	// messages exceeding the max size are skipped.
+	decoder = writer.NewDecoder(bytes.NewReader(data))
+	decoder.SetMaxMessageSize(len(data) / 3)
+	require.Nil(t, decoder.Decode(&str))
+	err := decoder.Decode(&bar)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "exceeds max size")
+	require.Nil(t, decoder.Decode(&str))
+	require.Equal(t, "str2", str)
+	decoder = NewDecoder(bytes.NewReader(data[:len(data)-1]))
+	decoder.SetMaxMessageSize(2)
+	require.Error(t, decoder.Decode(&str))
+	require.Error(t, decoder.Decode(&str))
+	require.Equal(t, io.ErrUnexpectedEOF, decoder.Decode(&str))
+
+	// the stream can't be positioned after a message of a corrupted size.
+	header := make([]byte, binary.MaxVarintLen64)
+	header = header[:binary.PutUvarint(header, math.MaxUint64)]
+	decoder = NewDecoder(bytes.NewReader(append(header, data...)))
+	err = decoder.Decode(&str)
+	require.Error(t, err)
+	require.Equal(t, err, decoder.Decode(&str))
+}
@@ -21,8 +21,10 @@ import (
 	"fmt"
 	"reflect"
 	"sort"
+	"strings"
 	"unicode"
 	"unicode/utf8"
+	"unsafe"
 )
 
 type structSerializer struct {
@@ -30,41 +32,106 @@ type structSerializer struct {
 	type_      reflect.Type
 	fieldsInfo structFieldsInfo
 	structHash int32
+	// fields meta used by compatible mode.
+	typeDefBytes []byte
+	fieldsByName map[string]*fieldInfo
+	// matched local fields of peer type defs, keyed by type def header.
+	peerFields    map[int64]structFieldsInfo
+	hasUnexported bool
+	// fields serializer generated by furygen, nil if the struct is serialized by reflection.
+	generated       GeneratedSerializer
+	generatedFields *StructFields
 }
 
 func (s *structSerializer) TypeId() TypeId {
+	if s.typeTag == "" {
+		return NotSupportCrossLanguage
+	}
 	return -FURY_TYPE_TAG
 }
 
-func (s *structSerializer) Write(f *Fury, buf *ByteBuffer, value reflect.Value) error {
-	// TODO support fields back and forward compatible. need to serialize fields name too.
+func (s *structSerializer) init(f *Fury) error {
 	if s.fieldsInfo == nil {
 		if fieldsInfo, err := createStructFieldInfos(f, s.type_); err != nil {
 			return err
 		} else {
 			s.fieldsInfo = fieldsInfo
 		}
+		for _, fieldInfo_ := range s.fieldsInfo {
+			s.hasUnexported = s.hasUnexported || fieldInfo_.unexported
+		}
+		if err := s.initGenerated(); err != nil {
+			return err
+		}
 	}
-	if s.structHash == 0 {
+	if f.compatibleMode == COMPATIBLE {
+		if s.typeDefBytes == nil {
+			s.fieldsByName = make(map[string]*fieldInfo, len(s.fieldsInfo))
+			for _, fieldInfo_ := range s.fieldsInfo {
+				typeBuffer := NewByteBuffer(nil)
+				encodeStructFieldType(f.typeResolver, typeBuffer, fieldInfo_)
+				fieldInfo_.typeBytes = typeBuffer.GetByteSlice(0, typeBuffer.WriterIndex())
+				s.fieldsByName[fieldInfo_.name] = fieldInfo_
+			}
+			if typeDefBytes, err := encodeTypeDef(s.fieldsInfo, f.referenceTracking); err != nil {
+				return err
+			} else {
+				s.typeDefBytes = typeDefBytes
+			}
+			s.peerFields = map[int64]structFieldsInfo{}
+		}
+	} else if s.structHash == 0 {
 		if hash, err := computeStructHash(s.fieldsInfo, f.typeResolver); err != nil {
 			return err
 		} else {
 			s.structHash = hash
 		}
 	}
+	return nil
+}
 
-	buf.WriteInt32(s.structHash)
+// initGenerated sets the generated serializer of the struct if it's registered, which must write the same
+// fields as reflection.
+func (s *structSerializer) initGenerated() error {
+	generated := getGeneratedSerializer(s.type_)
+	if generated == nil {
+		return nil
+	}
+	names := generated.Fields()
+	matched := len(names) == len(s.fieldsInfo)
+	for i := 0; matched && i < len(names); i++ {
+		matched = names[i] == s.fieldsInfo[i].field.Name
+	}
+	if !matched {
+		return fmt.Errorf("generated serializer of %s with fields %v doesn't match the struct, it should be "+
+			"regenerated", s.type_, names)
+	}
+	s.generated = generated
+	s.generatedFields = &StructFields{fieldsInfo: s.fieldsInfo}
+	return nil
+}
+
+func (s *structSerializer) Write(f *Fury, buf *ByteBuffer, value reflect.Value) error {
+	if err := s.init(f); err != nil {
+		return err
+	}
+	if (s.hasUnexported || s.generated != nil) && !value.CanAddr() {
+		// unexported fields and generated serializers can only access fields by address.
+		newValue := reflect.New(s.type_).Elem()
+		newValue.Set(value)
+		value = newValue
+	}
+	if f.compatibleMode == COMPATIBLE {
+		f.typeResolver.writeTypeDef(buf, s.type_, s.typeDefBytes)
+	} else {
+		buf.WriteInt32(s.structHash)
+	}
+	if s.generated != nil {
+		return s.generated.WriteFields(f, buf, s.generatedFields, value.Addr().Interface())
+	}
 	for _, fieldInfo_ := range s.fieldsInfo {
-		fieldValue := value.Field(fieldInfo_.fieldIndex)
-		if fieldInfo_.serializer != nil {
-			err := writeBySerializer(f, buf, fieldValue, fieldInfo_.serializer, fieldInfo_.referencable)
-			if err != nil {
-				return err
-			}
-		} else {
-			if err := f.WriteReferencable(buf, fieldValue); err != nil {
-				return err
-			}
+		if err := writeField(f, buf, fieldInfo_, fieldInfo_.valueOf(value)); err != nil {
+			return err
 		}
 	}
 	return nil
@@ -73,73 +140,277 @@ func (s *structSerializer) Write(f *Fury, buf *ByteBuffer, value reflect.Value)
 func (s *structSerializer) Read(f *Fury, buf *ByteBuffer, type_ reflect.Type, value reflect.Value) error {
 	// struct value may be a value type if it's not a pointer, so we don't invoke `refResolver.Reference` here,
 	// but invoke it in `ptrToStructSerializer` instead.
-	if s.fieldsInfo == nil {
-		if infos, err := createStructFieldInfos(f, s.type_); err != nil {
-			return err
-		} else {
-			s.fieldsInfo = infos
-		}
+	if err := s.init(f); err != nil {
+		return err
 	}
-	if s.structHash == 0 {
-		if hash, err := computeStructHash(s.fieldsInfo, f.typeResolver); err != nil {
-			return err
-		} else {
-			s.structHash = hash
-		}
+	if f.compatibleMode == COMPATIBLE {
+		return s.readCompatible(f, buf, value)
 	}
-
 	structHash := buf.ReadInt32()
 	if structHash != s.structHash {
 		return fmt.Errorf("hash %d is not consistent with %d for type %s",
 			structHash, s.structHash, s.type_)
 	}
+	if s.generated != nil && value.CanAddr() {
+		return s.generated.ReadFields(f, buf, s.generatedFields, value.Addr().Interface())
+	}
 	for _, fieldInfo_ := range s.fieldsInfo {
-		fieldValue := value.Field(fieldInfo_.fieldIndex)
-		fieldSerializer := fieldInfo_.serializer
-		if fieldSerializer != nil {
-			if err := readBySerializer(f, buf, fieldValue, fieldSerializer, fieldInfo_.referencable); err != nil {
-				return err
-			}
-		} else {
-			if err := f.ReadReferencable(buf, fieldValue); err != nil {
+		fieldValue := fieldInfo_.valueOf(value)
+		if err := readField(f, buf, fieldValue, fieldInfo_.serializer, fieldInfo_.nullable,
+			fieldInfo_.referencable); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// readCompatible reads fields by the type def written by peer. Fields are matched by name and type, fields
+// which don't exist locally are skipped, and local fields which don't exist in peer keep zero value.
+func (s *structSerializer) readCompatible(f *Fury, buf *ByteBuffer, value reflect.Value) error {
+	def, err := f.typeResolver.readTypeDef(buf)
+	if err != nil {
+		return err
+	}
+	fields, ok := s.peerFields[def.header]
+	if !ok {
+		fields = matchFields(s.fieldsByName, def)
+		s.peerFields[def.header] = fields
+	}
+	for i, fieldInfo_ := range fields {
+		if fieldInfo_ == nil {
+			if err := skipField(f, buf, def.fields[i]); err != nil {
 				return err
 			}
+			continue
+		}
+		// null and ref flags are written based on peer field.
+		fieldValue, peerField := fieldInfo_.valueOf(value), def.fields[i]
+		if err := readField(f, buf, fieldValue, fieldInfo_.serializer, peerField.nullable,
+			peerField.trackingRef); err != nil {
+			return err
 		}
 	}
 	return nil
 }
 
+// writeField writes the field value with the null and ref flag decided by the nullable and ref options of the
+// field. Nil slices and maps of fields which aren't nullable are written as empty ones.
+func writeField(f *Fury, buf *ByteBuffer, fieldInfo_ *fieldInfo, value reflect.Value) error {
+	switch {
+	case fieldInfo_.referencable:
+		return f.writeReferencableBySerializer(buf, value, fieldInfo_.serializer)
+	case fieldInfo_.nullable:
+		return f.writeNullableBySerializer(buf, value, fieldInfo_.serializer)
+	default:
+		if kind := value.Kind(); (kind == reflect.Ptr || kind == reflect.Interface) && value.IsNil() {
+			return fmt.Errorf("field %s isn't nullable but got nil", fieldInfo_.field.Name)
+		}
+		return f.writeNonReferencableBySerializer(buf, value, fieldInfo_.serializer)
+	}
+}
+
+// readField reads a field value written by `writeField`, `serializer` may be nil for interface fields.
+func readField(f *Fury, buf *ByteBuffer, value reflect.Value, serializer Serializer, nullable bool,
+	trackingRef bool) error {
+	if trackingRef {
+		return f.readReferencableBySerializer(buf, value, serializer)
+	}
+	flag := buf.ReadInt8()
+	if flag == NullFlag && nullable {
+		return nil
+	}
+	if flag != NotNullValueFlag {
+		return invalidDataError(buf, "unexpected flag %d of field", flag)
+	}
+	return f.readUntrackedData(buf, value, serializer)
+}
+
+// createStructFieldInfos returns the serialized fields of `type_` sorted by name. Fields of embedded structs are
+// flattened into the fields of `type_` by the promotion rules of golang, so a field of an embedded struct is
+// hidden by a field of the same name in a shallower depth, and ambiguous fields in the same depth are skipped.
+// Embedded pointers and embedded structs which aren't flattened, see `isFlattenedStruct`, are serialized as normal
+// fields.
 func createStructFieldInfos(f *Fury, type_ reflect.Type) (structFieldsInfo, error) {
 	var fields structFieldsInfo
-	for i := 0; i < type_.NumField(); i++ {
-		field := type_.Field(i)
+	for _, field := range reflect.VisibleFields(type_) {
+		if !isFlattenedPath(f.typeResolver, type_, field.Index) || isFlattenedStruct(f.typeResolver, field) {
+			continue
+		}
 		firstRune, _ := utf8.DecodeRuneInString(field.Name)
-		if unicode.IsLower(firstRune) {
+		unexported := unicode.IsLower(firstRune)
+		if unexported && !f.config.unexportedFields {
+			continue
+		}
+		tag, err := parseFieldTag(field)
+		if err != nil {
+			return nil, err
+		}
+		if tag.ignored {
 			continue
 		}
+		name := tag.name
+		if name == "" {
+			name = SnakeCase(field.Name) // TODO field name to lower case
+		}
 		fieldSerializer, _ := f.typeResolver.getSerializerByType(field.Type)
+		if tag.hasIntEncoding {
+			if fieldSerializer, err = intFieldSerializer(field, tag.intEncoding); err != nil {
+				return nil, err
+			}
+		} else if tag.set {
+			if fieldSerializer, err = newSetConcreteValueSerializer(f.typeResolver, field.Type); err != nil {
+				return nil, err
+			}
+		}
+		fieldNullable := nullable(field.Type) && !tag.notNullable
 		f := fieldInfo{
-			name:         SnakeCase(field.Name), // TODO field name to lower case
+			name:         name,
 			field:        field,
-			fieldIndex:   i,
+			fieldIndex:   field.Index,
 			type_:        field.Type,
-			referencable: nullable(field.Type),
+			nullable:     fieldNullable,
+			referencable: fieldNullable && !tag.noRef,
 			serializer:   fieldSerializer,
+			unexported:   unexported,
 		}
 		fields = append(fields, &f)
 	}
 	sort.Sort(fields)
+	for i := 1; i < len(fields); i++ {
+		if fields[i].name == fields[i-1].name {
+			return nil, fmt.Errorf("fields %s and %s of %s have the same name %s",
+				fields[i-1].field.Name, fields[i].field.Name, type_, fields[i].name)
+		}
+	}
 	return fields, nil
 }
 
+// isFlattenedStruct returns whether `field` is an embedded struct whose fields are flattened into the fields of
+// its parent. It isn't flattened if it's skipped or named by fury tag, or it has its own serializer such as
+// `time.Time` and Marshaller types.
+func isFlattenedStruct(r *typeResolver, field reflect.StructField) bool {
+	if !field.Anonymous || field.Type.Kind() != reflect.Struct || isMarshaller(field.Type) {
+		return false
+	}
+	if serializer, ok := r.typeToSerializers[field.Type]; ok {
+		if _, ok := serializer.(*structSerializer); !ok {
+			return false
+		}
+	}
+	tag, err := parseFieldTag(field)
+	return err == nil && !tag.ignored && tag.name == ""
+}
+
+// isFlattenedPath returns whether the field of `index` in `type_` is only embedded by flattened structs.
+func isFlattenedPath(r *typeResolver, type_ reflect.Type, index []int) bool {
+	for _, i := range index[:len(index)-1] {
+		field := type_.Field(i)
+		if !isFlattenedStruct(r, field) {
+			return false
+		}
+		type_ = field.Type
+	}
+	return true
+}
+
+// fieldTag is the parsed `fury` struct tag of a field, which is `fury:"[name][,option]..."`, or `fury:"-"` to
+// skip the field. The name is the field name in the data, which decides the fields order and is used to match
+// fields in compatible mode, default to the snake case of the golang field name. Supported options:
+//   - nullable=true|false: whether the field can be nil. A field which isn't nullable is written without null
+//     and ref flag, and it's an error to write a nil pointer or interface. Default to true for pointers, slices,
+//     maps, interfaces and strings.
+//   - ref=true|false: whether to track the reference of the field if reference tracking is enabled. Default to
+//     true for nullable fields.
+//   - encoding=fixed|varint: the encoding of int32/int64/int fields, which overrides the int encoding of config.
+//     Varint fields can only be read by golang peers.
+//   - set: serialize a `map[T]bool` field as a set of the keys whose values are true, instead of a map. It's the
+//     only way to write a `map[T]bool` as a set, `map[T]bool` values without it are serialized as maps.
+type fieldTag struct {
+	name           string
+	ignored        bool
+	notNullable    bool
+	noRef          bool
+	intEncoding    IntEncoding
+	hasIntEncoding bool
+	set            bool
+}
+
+func parseFieldTag(field reflect.StructField) (fieldTag, error) {
+	var tag fieldTag
+	value, ok := field.Tag.Lookup("fury")
+	if !ok {
+		return tag, nil
+	}
+	if value == "-" {
+		tag.ignored = true
+		return tag, nil
+	}
+	parts := strings.Split(value, ",")
+	tag.name = parts[0]
+	for _, option := range parts[1:] {
+		switch option {
+		case "nullable=true", "ref=true":
+			if !nullable(field.Type) {
+				return tag, fmt.Errorf("option %s can't be used by field %s of type %s", option, field.Name,
+					field.Type)
+			}
+		case "nullable=false":
+			tag.notNullable = true
+		case "ref=false":
+			tag.noRef = true
+		case "encoding=fixed":
+			tag.intEncoding, tag.hasIntEncoding = INT_ENCODING_FIXED, true
+		case "encoding=varint":
+			tag.intEncoding, tag.hasIntEncoding = INT_ENCODING_VARINT, true
+		case "set":
+			type_ := field.Type
+			if type_.Kind() != reflect.Map || type_.Elem().Kind() != reflect.Bool || isDynamicType(type_.Key()) {
+				return tag, fmt.Errorf("option set can't be used by field %s of type %s", field.Name, field.Type)
+			}
+			tag.set = true
+		default:
+			return tag, fmt.Errorf("unknown option %s in fury tag of field %s", option, field.Name)
+		}
+	}
+	return tag, nil
+}
+
+// intFieldSerializer returns the serializer of an int32/int64/int field with the int encoding of its tag.
+func intFieldSerializer(field reflect.StructField, encoding IntEncoding) (Serializer, error) {
+	basicType, ok := basicTypes[field.Type.Kind()]
+	serializer, isInt := intSerializers(encoding)[basicType]
+	if !ok || !isInt {
+		return nil, fmt.Errorf("encoding option can't be used by field %s of type %s", field.Name, field.Type)
+	}
+	if basicType != field.Type {
+		return &namedTypeSerializer{serializer}, nil
+	}
+	return serializer, nil
+}
+
 type fieldInfo struct {
-	name         string
-	field        reflect.StructField
-	fieldIndex   int
-	type_        reflect.Type
+	name       string
+	field      reflect.StructField
+	fieldIndex []int
+	type_      reflect.Type
+	// whether the field is written with a null flag, and whether its reference is tracked.
+	nullable     bool
 	referencable bool
 	// maybe be nil: for interface fields, we need to check whether the value is a Reference.
 	serializer Serializer
+	// encoded field type in type def, only set in compatible mode.
+	typeBytes  []byte
+	unexported bool
+}
+
+// valueOf returns the field value of `structValue`, which is settable for unexported fields too.
+func (f *fieldInfo) valueOf(structValue reflect.Value) reflect.Value {
+	fieldValue := structValue.FieldByIndex(f.fieldIndex)
+	if f.unexported {
+		// reflect doesn't allow setting or getting interface of unexported fields, access them by address instead.
+		return reflect.NewAt(f.type_, unsafe.Pointer(fieldValue.UnsafeAddr())).Elem()
+	}
+	return fieldValue
 }
 
 type structFieldsInfo []*fieldInfo
@@ -157,6 +428,9 @@ type ptrToStructSerializer struct {
 }
 
 func (s *ptrToStructSerializer) TypeId() TypeId {
+	if s.typeTag == "" {
+		return NotSupportCrossLanguage
+	}
 	return FURY_TYPE_TAG
 }
 
@@ -165,6 +439,7 @@ func (s *ptrToStructSerializer) Write(f *Fury, buf *ByteBuffer, value reflect.Va
 }
 
 func (s *ptrToStructSerializer) Read(f *Fury, buf *ByteBuffer, type_ reflect.Type, value reflect.Value) error {
+	f.allocate(buf, int(type_.Elem().Size()))
 	newValue := reflect.New(type_.Elem())
 	value.Set(newValue)
 	elem := newValue.Elem()
@@ -172,6 +447,8 @@ func (s *ptrToStructSerializer) Read(f *Fury, buf *ByteBuffer, type_ reflect.Typ
 	return s.structSerializer.Read(f, buf, type_.Elem(), elem)
 }
 
+// computeStructHash returns the hash of the fields of a struct in schema consistent mode. It isn't MurmurHash3 of the
+// type meta, since the struct serializers of java, python and rust compute it from the field type ids the same way.
 func computeStructHash(fieldsInfo structFieldsInfo, typeResolver *typeResolver) (int32, error) {
 	var hash int32 = 17
 	for _, f := range fieldsInfo {
@@ -188,7 +465,8 @@ func computeStructHash(fieldsInfo structFieldsInfo, typeResolver *typeResolver)
 }
 
 func computeFieldHash(hash int32, fieldInfo *fieldInfo, typeResolver *typeResolver) (int32, error) {
-	if serializer, err := typeResolver.getSerializerByType(fieldInfo.type_); err != nil {
+	// the serializer of field may be decided by its tag.
+	if serializer := fieldInfo.serializer; serializer == nil {
 		// FIXME ignore unknown types for hash calculation
 		return hash, nil
 	} else {
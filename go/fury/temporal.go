@@ -0,0 +1,247 @@
+// Licensed to the Apache Software Foundation (ASF) under one
+// or more contributor license agreements.  See the NOTICE file
+// distributed with this work for additional information
+// regarding copyright ownership.  The ASF licenses this file
+// to you under the Apache License, Version 2.0 (the
+// "License"); you may not use this file except in compliance
+// with the License.  You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package fury
+
+import (
+	"fmt"
+	"reflect"
+	"time"
+)
+
+const (
+	secondsPerDay      = 24 * 60 * 60
+	millisecondsPerDay = secondsPerDay * 1000
+)
+
+// Date represents an imprecise date. Dates out of range are normalized when they are written as `time.Date` does,
+// for example, Date{2020, 0, 0} is written as 2019-11-30.
+type Date struct {
+	Year  int        // Year. E.g., 2009.
+	Month time.Month // Month is 1 - 12.
+	Day   int        // Day is 1 - 31.
+}
+
+// TimeOfDay is the time elapsed since midnight, such as `TimeOfDay(9*time.Hour + 30*time.Minute)`, which must be
+// in [0, 24h).
+type TimeOfDay time.Duration
+
+// IntervalMonths is a YEAR_MONTH interval in SQL style, such as `INTERVAL '1-2' YEAR TO MONTH` of 14 months.
+type IntervalMonths int32
+
+// IntervalDayTime is a DAY_TIME interval in SQL style, such as `INTERVAL '1 02:00:00' DAY TO SECOND`.
+type IntervalDayTime struct {
+	Days         int32
+	Milliseconds int32
+}
+
+// unitSerializers read the temporal type ids which are written by peers in other units than golang values, such as
+// DATE64 in milliseconds, into the types of those values.
+var unitSerializers = map[int16]Serializer{
+	DATE64: date64Serializer{},
+	TIME32: time32Serializer{},
+}
+
+// floorDiv returns the floor of `x / y` for positive `y`.
+func floorDiv(x, y int64) int64 {
+	if q := x / y; x%y < 0 {
+		return q - 1
+	} else {
+		return q
+	}
+}
+
+func dateOfDays(days int64) Date {
+	t := time.Unix(days*secondsPerDay, 0).UTC()
+	return Date{t.Year(), t.Month(), t.Day()}
+}
+
+// dateSerializer writes a date as DATE32 days since the UNIX epoch. The days are counted in the calendar, which
+// don't depend on the time zone of the host.
+type dateSerializer struct {
+}
+
+func (s dateSerializer) TypeId() TypeId {
+	return DATE32
+}
+
+func (s dateSerializer) Write(f *Fury, buf *ByteBuffer, value reflect.Value) error {
+	date := value.Interface().(Date)
+	days := time.Date(date.Year, date.Month, date.Day, 0, 0, 0, 0, time.UTC).Unix() / secondsPerDay
+	if days < MinInt32 || days > MaxInt32 {
+		return fmt.Errorf("date %v is out of the range of DATE32", date)
+	}
+	buf.WriteInt32(int32(days))
+	return nil
+}
+
+func (s dateSerializer) Read(f *Fury, buf *ByteBuffer, type_ reflect.Type, value reflect.Value) error {
+	value.Set(reflect.ValueOf(dateOfDays(int64(buf.ReadInt32()))))
+	return nil
+}
+
+// date64Serializer reads DATE64 milliseconds since the UNIX epoch into a date.
+type date64Serializer struct {
+}
+
+func (s date64Serializer) TypeId() TypeId {
+	return DATE64
+}
+
+func (s date64Serializer) Write(f *Fury, buf *ByteBuffer, value reflect.Value) error {
+	return fmt.Errorf("dates are written as DATE32")
+}
+
+func (s date64Serializer) Read(f *Fury, buf *ByteBuffer, type_ reflect.Type, value reflect.Value) error {
+	value.Set(reflect.ValueOf(dateOfDays(floorDiv(buf.ReadInt64(), millisecondsPerDay))))
+	return nil
+}
+
+// timeSerializer writes a time as TIMESTAMP since the UNIX epoch in the unit of `WithTimestampUnit`. The location
+// isn't written, times are read in the local location as `time.Unix`.
+type timeSerializer struct {
+}
+
+func (s timeSerializer) TypeId() TypeId {
+	return TIMESTAMP
+}
+
+func (s timeSerializer) Write(f *Fury, buf *ByteBuffer, value reflect.Value) error {
+	t := value.Interface().(time.Time)
+	if f.config.timestampUnit == TIME_UNIT_MICRO {
+		buf.WriteInt64(GetUnixMicro(t))
+		return nil
+	}
+	nanoseconds := t.UnixNano()
+	if !time.Unix(0, nanoseconds).Equal(t) {
+		return fmt.Errorf("time %s is out of the range of nanosecond timestamp", t)
+	}
+	buf.WriteInt64(nanoseconds)
+	return nil
+}
+
+func (s timeSerializer) Read(f *Fury, buf *ByteBuffer, type_ reflect.Type, value reflect.Value) error {
+	if f.config.timestampUnit == TIME_UNIT_MICRO {
+		value.Set(reflect.ValueOf(CreateTimeFromUnixMicro(buf.ReadInt64())))
+	} else {
+		value.Set(reflect.ValueOf(time.Unix(0, buf.ReadInt64())))
+	}
+	return nil
+}
+
+// timeOfDaySerializer writes a time of day as TIME64 nanoseconds since midnight.
+type timeOfDaySerializer struct {
+}
+
+func (s timeOfDaySerializer) TypeId() TypeId {
+	return TIME64
+}
+
+func (s timeOfDaySerializer) Write(f *Fury, buf *ByteBuffer, value reflect.Value) error {
+	t := TimeOfDay(value.Int())
+	if t < 0 || time.Duration(t) >= 24*time.Hour {
+		return fmt.Errorf("time of day %s is out of [0, 24h)", time.Duration(t))
+	}
+	buf.WriteInt64(int64(t))
+	return nil
+}
+
+func (s timeOfDaySerializer) Read(f *Fury, buf *ByteBuffer, type_ reflect.Type, value reflect.Value) error {
+	t := buf.ReadInt64()
+	if t < 0 || time.Duration(t) >= 24*time.Hour {
+		return invalidDataError(buf, "time of day %d ns is out of [0, 24h)", t)
+	}
+	value.SetInt(t)
+	return nil
+}
+
+// time32Serializer reads TIME32 milliseconds since midnight into a time of day.
+type time32Serializer struct {
+}
+
+func (s time32Serializer) TypeId() TypeId {
+	return TIME32
+}
+
+func (s time32Serializer) Write(f *Fury, buf *ByteBuffer, value reflect.Value) error {
+	return fmt.Errorf("times of day are written as TIME64")
+}
+
+func (s time32Serializer) Read(f *Fury, buf *ByteBuffer, type_ reflect.Type, value reflect.Value) error {
+	t := buf.ReadInt32()
+	if t < 0 || t >= millisecondsPerDay {
+		return invalidDataError(buf, "time of day %d ms is out of [0, 24h)", t)
+	}
+	value.SetInt(int64(t) * int64(time.Millisecond))
+	return nil
+}
+
+// durationSerializer writes a duration as DURATION nanoseconds, which is the precision of `time.Duration`.
+type durationSerializer struct {
+}
+
+func (s durationSerializer) TypeId() TypeId {
+	return DURATION
+}
+
+func (s durationSerializer) Write(f *Fury, buf *ByteBuffer, value reflect.Value) error {
+	buf.WriteInt64(value.Int())
+	return nil
+}
+
+func (s durationSerializer) Read(f *Fury, buf *ByteBuffer, type_ reflect.Type, value reflect.Value) error {
+	value.SetInt(buf.ReadInt64())
+	return nil
+}
+
+type intervalMonthsSerializer struct {
+}
+
+func (s intervalMonthsSerializer) TypeId() TypeId {
+	return INTERVAL_MONTHS
+}
+
+func (s intervalMonthsSerializer) Write(f *Fury, buf *ByteBuffer, value reflect.Value) error {
+	buf.WriteInt32(int32(value.Int()))
+	return nil
+}
+
+func (s intervalMonthsSerializer) Read(f *Fury, buf *ByteBuffer, type_ reflect.Type, value reflect.Value) error {
+	value.SetInt(int64(buf.ReadInt32()))
+	return nil
+}
+
+// intervalDayTimeSerializer writes the days and milliseconds of an interval as two int32, the same as arrow.
+type intervalDayTimeSerializer struct {
+}
+
+func (s intervalDayTimeSerializer) TypeId() TypeId {
+	return INTERVAL_DAY_TIME
+}
+
+func (s intervalDayTimeSerializer) Write(f *Fury, buf *ByteBuffer, value reflect.Value) error {
+	interval := value.Interface().(IntervalDayTime)
+	buf.WriteInt32(interval.Days)
+	buf.WriteInt32(interval.Milliseconds)
+	return nil
+}
+
+func (s intervalDayTimeSerializer) Read(f *Fury, buf *ByteBuffer, type_ reflect.Type, value reflect.Value) error {
+	days := buf.ReadInt32()
+	value.Set(reflect.ValueOf(IntervalDayTime{Days: days, Milliseconds: buf.ReadInt32()}))
+	return nil
+}
@@ -0,0 +1,137 @@
+// Licensed to the Apache Software Foundation (ASF) under one
+// or more contributor license agreements.  See the NOTICE file
+// distributed with this work for additional information
+// regarding copyright ownership.  The ASF licenses this file
+// to you under the Apache License, Version 2.0 (the
+// "License"); you may not use this file except in compliance
+// with the License.  You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package fury
+
+import (
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+func TestDateIsIndependentOfTimeZone(t *testing.T) {
+	local := time.Local
+	defer func() {
+		time.Local = local
+	}()
+	fury := NewFury()
+	for _, location := range []string{"UTC", "America/Los_Angeles", "Asia/Shanghai", "Pacific/Kiritimati"} {
+		loc, err := time.LoadLocation(location)
+		if err != nil {
+			t.Skipf("time zone database is unavailable: %s", err)
+		}
+		time.Local = loc
+		for date, days := range map[Date]int32{
+			{1970, 1, 1}: 0, {2021, 11, 23}: 18954, {1969, 12, 31}: -1, {2024, 3, 10}: 19792, {1900, 1, 1}: -25567,
+		} {
+			bytes, err := fury.Marshal(date)
+			require.Nil(t, err)
+			buf := NewByteBuffer(bytes[len(bytes)-4:])
+			require.Equal(t, days, buf.ReadInt32(), date)
+			var newDate Date
+			require.Nil(t, fury.Unmarshal(bytes, &newDate))
+			require.Equal(t, date, newDate, location)
+		}
+	}
+}
+
+func TestSerializeTemporal(t *testing.T) {
+	type Schedule struct {
+		Day      Date
+		Start    TimeOfDay
+		Length   time.Duration
+		Created  time.Time
+		Term     IntervalMonths
+		Reminder IntervalDayTime
+	}
+	schedule := Schedule{
+		Day:      Date{2021, 11, 23},
+		Start:    TimeOfDay(9*time.Hour + 30*time.Minute + time.Nanosecond),
+		Length:   90 * time.Minute,
+		Created:  time.Unix(1700000000, 123456000),
+		Term:     14,
+		Reminder: IntervalDayTime{Days: 1, Milliseconds: 2 * 3600 * 1000},
+	}
+	for _, language := range []Language{XLANG, GO} {
+		fury := NewFury(WithLanguage(language))
+		require.Nil(t, fury.RegisterTagType("example.Schedule", Schedule{}))
+		for _, value := range []interface{}{schedule.Day, schedule.Start, schedule.Length, schedule.Created,
+			schedule.Term, schedule.Reminder, []interface{}{schedule.Day, schedule.Length}, schedule} {
+			serde(t, fury, value)
+		}
+		_, err := fury.Marshal(TimeOfDay(24 * time.Hour))
+		require.Error(t, err)
+	}
+}
+
+func TestTimestampUnit(t *testing.T) {
+	instant := time.Unix(1700000000, 123456789)
+	fury := NewFury()
+	bytes, err := fury.Marshal(instant)
+	require.Nil(t, err)
+	require.Equal(t, int64(1700000000123456), NewByteBuffer(bytes[len(bytes)-8:]).ReadInt64())
+	var newInstant time.Time
+	require.Nil(t, fury.Unmarshal(bytes, &newInstant))
+	require.Equal(t, instant.Truncate(time.Microsecond), newInstant)
+
+	fury = NewFury(WithTimestampUnit(TIME_UNIT_NANO))
+	bytes, err = fury.Marshal(instant)
+	require.Nil(t, err)
+	require.Equal(t, instant.UnixNano(), NewByteBuffer(bytes[len(bytes)-8:]).ReadInt64())
+	require.Nil(t, fury.Unmarshal(bytes, &newInstant))
+	require.Equal(t, instant, newInstant)
+	_, err = fury.Marshal(time.Date(3000, 1, 1, 0, 0, 0, 0, time.UTC))
+	require.Error(t, err)
+}
+
+func TestReadPeerTemporalUnits(t *testing.T) {
+	fury := NewFury()
+	bytes, err := fury.Marshal(Date{})
+	require.Nil(t, err)
+	data := func(typeId TypeId, write func(buf *ByteBuffer)) []byte {
+		// replace the type id and the days of the date.
+		buf := NewByteBuffer(append([]byte(nil), bytes[:len(bytes)-6]...))
+		buf.SetWriterIndex(len(bytes) - 6)
+		buf.WriteInt16(typeId)
+		write(buf)
+		return buf.GetByteSlice(0, buf.WriterIndex())
+	}
+	date64 := data(DATE64, func(buf *ByteBuffer) {
+		buf.WriteInt64(-millisecondsPerDay - 1)
+	})
+	var date Date
+	require.Nil(t, fury.Unmarshal(date64, &date))
+	require.Equal(t, Date{1969, 12, 30}, date)
+	var value interface{}
+	require.Nil(t, fury.Unmarshal(date64, &value))
+	require.Equal(t, Date{1969, 12, 30}, value)
+	// dates out of range are normalized.
+	for date, normalized := range map[Date]Date{{}: {-1, 11, 30}, {2020, 0, 0}: {2019, 11, 30}, {2021, 2, 29}: {2021, 3, 1}} {
+		bytes, err := fury.Marshal(date)
+		require.Nil(t, err)
+		require.Nil(t, fury.Unmarshal(bytes, &value))
+		require.Equal(t, normalized, value)
+	}
+	time32 := data(TIME32, func(buf *ByteBuffer) {
+		buf.WriteInt32(3_600_001)
+	})
+	var timeOfDay TimeOfDay
+	require.Nil(t, fury.Unmarshal(time32, &timeOfDay))
+	require.Equal(t, TimeOfDay(time.Hour+time.Millisecond), timeOfDay)
+	var interval IntervalDayTime
+	require.Error(t, fury.Unmarshal(date64, &interval))
+}
@@ -0,0 +1,131 @@
+// Licensed to the Apache Software Foundation (ASF) under one
+// or more contributor license agreements.  See the NOTICE file
+// distributed with this work for additional information
+// regarding copyright ownership.  The ASF licenses this file
+// to you under the Apache License, Version 2.0 (the
+// "License"); you may not use this file except in compliance
+// with the License.  You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package fury
+
+import (
+	"fmt"
+	"sync"
+)
+
+// ThreadSafeFury is a Fury which can be used by multiple goroutines concurrently. `Fury` isn't thread safe
+// since it holds the states of current serialization, so ThreadSafeFury pools Fury instances created with the
+// same config and registered by the same callback, and every call takes an instance from the pool exclusively.
+type ThreadSafeFury struct {
+	config   *Config
+	register func(f *Fury) error
+	pool     sync.Pool
+}
+
+// NewThreadSafeFury creates a ThreadSafeFury whose Fury instances are created by `config` and then set up by
+// `register`, which should register all types those instances need. `config` can be nil to use default options,
+// and `register` can be nil if no types need to be registered. `register` will be invoked once for every pooled
+// instance, an error is returned if it fails.
+func NewThreadSafeFury(config *Config, register func(f *Fury) error) (*ThreadSafeFury, error) {
+	if config == nil {
+		var err error
+		if config, err = NewConfig(); err != nil {
+			return nil, err
+		}
+	}
+	f := &ThreadSafeFury{config: config, register: register}
+	// create the first instance eagerly to report registration errors.
+	fury, err := f.newFury()
+	if err != nil {
+		return nil, err
+	}
+	f.pool.New = func() interface{} {
+		fury, err := f.newFury()
+		if err != nil {
+			// `register` succeeded on the first instance, so it should never fail here.
+			panic(err)
+		}
+		return fury
+	}
+	f.pool.Put(fury)
+	return f, nil
+}
+
+func (f *ThreadSafeFury) newFury() (*Fury, error) {
+	fury := NewFury(WithConfig(f.config))
+	if f.register != nil {
+		if err := f.register(fury); err != nil {
+			return nil, err
+		}
+	}
+	return fury, nil
+}
+
+// Config returns the config used to create Fury instances.
+func (f *ThreadSafeFury) Config() *Config {
+	return f.config
+}
+
+// Execute invokes `action` with a Fury which is used by current goroutine exclusively until `action` returns.
+// The Fury shouldn't be retained after `action` returns. If `action` panics, the Fury is dropped instead of being
+// returned to the pool, since its states may be left in the middle of a serialization.
+func (f *ThreadSafeFury) Execute(action func(fury *Fury) error) error {
+	fury := f.pool.Get().(*Fury)
+	err := action(fury)
+	f.pool.Put(fury)
+	return err
+}
+
+// Marshal returns the fury encoding of v. The returned bytes are owned by the caller.
+func (f *ThreadSafeFury) Marshal(v interface{}) ([]byte, error) {
+	var data []byte
+	err := f.Execute(func(fury *Fury) (err error) {
+		data, err = fury.Marshal(v)
+		return
+	})
+	return data, err
+}
+
+// MarshalAppend appends the fury encoding of v to dst and returns the extended buffer.
+func (f *ThreadSafeFury) MarshalAppend(dst []byte, v interface{}) ([]byte, error) {
+	err := f.Execute(func(fury *Fury) (err error) {
+		dst, err = fury.MarshalAppend(dst, v)
+		return
+	})
+	return dst, err
+}
+
+// Unmarshal decodes the fury-encoded data and stores the result in the value pointed to by v.
+func (f *ThreadSafeFury) Unmarshal(data []byte, v interface{}) error {
+	return f.Execute(func(fury *Fury) error {
+		return fury.Unmarshal(data, v)
+	})
+}
+
+// Serialize writes the fury encoding of v into `buf`, which must not be nil since the pooled buffer of Fury
+// can't be accessed by the caller.
+func (f *ThreadSafeFury) Serialize(buf *ByteBuffer, v interface{}, callback BufferCallback) error {
+	if buf == nil {
+		return fmt.Errorf("buffer shouldn't be nil, use Marshal instead")
+	}
+	return f.Execute(func(fury *Fury) error {
+		return fury.Serialize(buf, v, callback)
+	})
+}
+
+// Deserialize reads a value from `buf` and stores it in the value pointed to by v, `buffers` are the out-of-band
+// buffers of the data, see Fury.Deserialize.
+func (f *ThreadSafeFury) Deserialize(buf *ByteBuffer, v interface{}, buffers []*ByteBuffer) error {
+	return f.Execute(func(fury *Fury) error {
+		return fury.Deserialize(buf, v, buffers)
+	})
+}
@@ -0,0 +1,107 @@
+// Licensed to the Apache Software Foundation (ASF) under one
+// or more contributor license agreements.  See the NOTICE file
+// distributed with this work for additional information
+// regarding copyright ownership.  The ASF licenses this file
+// to you under the Apache License, Version 2.0 (the
+// "License"); you may not use this file except in compliance
+// with the License.  You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package fury
+
+import (
+	"fmt"
+	"github.com/stretchr/testify/require"
+	"sync"
+	"testing"
+)
+
+func TestThreadSafeFury(t *testing.T) {
+	config, err := NewConfig(WithRefTracking(true))
+	require.Nil(t, err)
+	fury, err := NewThreadSafeFury(config, func(f *Fury) error {
+		if err := f.RegisterTagType("example.Bar", Bar{}); err != nil {
+			return err
+		}
+		return f.RegisterTagType("example.Foo", Foo{})
+	})
+	require.Nil(t, err)
+	require.Same(t, config, fury.Config())
+
+	var wg sync.WaitGroup
+	errs := make(chan error, 16)
+	for i := 0; i < 16; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				foo := newFoo()
+				foo.F2 = fmt.Sprintf("str%d_%d", i, j)
+				bytes, err := fury.Marshal(&foo)
+				if err != nil {
+					errs <- err
+					return
+				}
+				var newFoo *Foo
+				if err := fury.Unmarshal(bytes, &newFoo); err != nil {
+					errs <- err
+					return
+				}
+				if foo.F2 != newFoo.F2 {
+					errs <- fmt.Errorf("expect %s, got %s", foo.F2, newFoo.F2)
+					return
+				}
+			}
+		}(i)
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		require.Nil(t, err)
+	}
+
+	buf := NewByteBuffer(nil)
+	require.Nil(t, fury.Serialize(buf, Bar{F1: 1}, nil))
+	var bar Bar
+	require.Nil(t, fury.Deserialize(buf, &bar, nil))
+	require.Equal(t, Bar{F1: 1}, bar)
+	require.Error(t, fury.Serialize(nil, Bar{}, nil))
+
+	// instances are dropped if the action panics.
+	var panicked *Fury
+	require.Panics(t, func() {
+		_ = fury.Execute(func(f *Fury) error {
+			panicked = f
+			panic("action failed")
+		})
+	})
+	for i := 0; i < 4; i++ {
+		require.Nil(t, fury.Execute(func(f *Fury) error {
+			require.NotSame(t, panicked, f)
+			return nil
+		}))
+	}
+}
+
+func TestThreadSafeFuryRegisterError(t *testing.T) {
+	_, err := NewThreadSafeFury(nil, func(f *Fury) error {
+		return fmt.Errorf("register failed")
+	})
+	require.Error(t, err)
+	fury, err := NewThreadSafeFury(nil, nil)
+	require.Nil(t, err)
+	require.False(t, fury.Config().ReferenceTracking())
+	bytes, err := fury.Marshal([]string{"str1", "str2"})
+	require.Nil(t, err)
+	var newValue []string
+	require.Nil(t, fury.Unmarshal(bytes, &newValue))
+	require.Equal(t, []string{"str1", "str2"}, newValue)
+}
@@ -20,9 +20,8 @@ package fury
 import (
 	"fmt"
 	"github.com/apache/fury/go/fury/meta"
-	"hash/fnv"
+	"math/big"
 	"reflect"
-	"regexp"
 	"strconv"
 	"strings"
 	"time"
@@ -131,6 +130,10 @@ const (
 	FURY_BUFFER                 = 266
 	FURY_ARROW_RECORD_BATCH     = 267
 	FURY_ARROW_TABLE            = 268
+	// FURY_VAR_INT32 and FURY_VAR_INT64 are int32 and int64 in signed varint encoding, they are golang specific
+	// and unknown to the peers of other languages.
+	FURY_VAR_INT32 = 269
+	FURY_VAR_INT64 = 270
 )
 
 const (
@@ -140,6 +143,12 @@ const (
 	SMALL_STRING_THRESHOLD  = 16
 )
 
+var (
+	// type tags and type infos are meta strings of namespaces and type names, whose special chars are `.` and `_`.
+	typeMetaStringEncoder = meta.NewEncoder('.', '_')
+	typeMetaStringDecoder = meta.NewDecoder('.', '_')
+)
+
 var (
 	interfaceType = reflect.TypeOf((*interface{})(nil)).Elem()
 	stringType    = reflect.TypeOf((*string)(nil)).Elem()
@@ -154,6 +163,10 @@ var (
 	int64SliceType     = reflect.TypeOf((*[]int64)(nil)).Elem()
 	float32SliceType   = reflect.TypeOf((*[]float32)(nil)).Elem()
 	float64SliceType   = reflect.TypeOf((*[]float64)(nil)).Elem()
+	uint16SliceType    = reflect.TypeOf((*[]uint16)(nil)).Elem()
+	uint32SliceType    = reflect.TypeOf((*[]uint32)(nil)).Elem()
+	uint64SliceType    = reflect.TypeOf((*[]uint64)(nil)).Elem()
+	uintSliceType      = reflect.TypeOf((*[]uint)(nil)).Elem()
 	interfaceSliceType = reflect.TypeOf((*[]interface{})(nil)).Elem()
 	interfaceMapType   = reflect.TypeOf((*map[interface{}]interface{})(nil)).Elem()
 	boolType           = reflect.TypeOf((*bool)(nil)).Elem()
@@ -163,11 +176,27 @@ var (
 	int32Type          = reflect.TypeOf((*int32)(nil)).Elem()
 	int64Type          = reflect.TypeOf((*int64)(nil)).Elem()
 	intType            = reflect.TypeOf((*int)(nil)).Elem()
+	uint16Type         = reflect.TypeOf((*uint16)(nil)).Elem()
+	uint32Type         = reflect.TypeOf((*uint32)(nil)).Elem()
+	uint64Type         = reflect.TypeOf((*uint64)(nil)).Elem()
+	uintType           = reflect.TypeOf((*uint)(nil)).Elem()
+	uintptrType        = reflect.TypeOf((*uintptr)(nil)).Elem()
 	float32Type        = reflect.TypeOf((*float32)(nil)).Elem()
 	float64Type        = reflect.TypeOf((*float64)(nil)).Elem()
+	complex64Type      = reflect.TypeOf((*complex64)(nil)).Elem()
+	complex128Type     = reflect.TypeOf((*complex128)(nil)).Elem()
 	dateType           = reflect.TypeOf((*Date)(nil)).Elem()
 	timestampType      = reflect.TypeOf((*time.Time)(nil)).Elem()
+	timeOfDayType      = reflect.TypeOf((*TimeOfDay)(nil)).Elem()
+	durationType       = reflect.TypeOf((*time.Duration)(nil)).Elem()
+	intervalMonthsType = reflect.TypeOf((*IntervalMonths)(nil)).Elem()
+	intervalDayType    = reflect.TypeOf((*IntervalDayTime)(nil)).Elem()
 	genericSetType     = reflect.TypeOf((*GenericSet)(nil)).Elem()
+	emptyStructType    = reflect.TypeOf((*struct{})(nil)).Elem()
+	decimalType        = reflect.TypeOf((*Decimal)(nil)).Elem()
+	bigIntPtrType      = reflect.TypeOf((*big.Int)(nil))
+	bigRatPtrType      = reflect.TypeOf((*big.Rat)(nil))
+	bigFloatPtrType    = reflect.TypeOf((*big.Float)(nil))
 )
 
 type typeResolver struct {
@@ -180,6 +209,42 @@ type typeResolver struct {
 	dynamicStringToId    map[string]int16
 	dynamicIdToString    map[int16]string
 	dynamicStringId      int16
+	// encodedMetaStrings caches the meta strings of written type tags and type infos, which are encoded once.
+	encodedMetaStrings map[string]*metaStringBytes
+	// extIdToType is the types of ext ids of Marshaller types, which are used to read values into interfaces.
+	extIdToType map[int16]reflect.Type
+	// implementations maps an interface type to its registered implementations, every implementation is a struct
+	// type which maps to the type of its values in the interface, the struct itself or a pointer to it.
+	implementations map[reflect.Type]map[reflect.Type]reflect.Type
+	// valueImplementations is the struct types registered as implementations by values, which are written with
+	// their type tags, and read into interface values as values rather than pointers.
+	valueImplementations map[reflect.Type]bool
+	// globalTypes is the number of types of `Register` which have been registered.
+	globalTypes int32
+	// type defs of structs written/read in current serialization for compatible mode.
+	writtenTypeDefs map[reflect.Type]int32
+	readTypeDefs    []*typeDef
+	// typeChecker checks type tags and type infos read from the data, nil means all types are allowed.
+	typeChecker TypeChecker
+}
+
+// unregisteredTagError is returned when the data has a type tag which isn't registered.
+type unregisteredTagError struct {
+	tag string
+}
+
+func (e *unregisteredTagError) Error() string {
+	return fmt.Sprintf("type tag %s is not registered", e.tag)
+}
+
+// arrayLengthError is returned when an array type read from the data has more elements than the data can hold.
+type arrayLengthError struct {
+	typeStr string
+	max     int
+}
+
+func (e *arrayLengthError) Error() string {
+	return fmt.Sprintf("array type %s has more than %d elements", e.typeStr, e.max)
 }
 
 func newTypeResolver() *typeResolver {
@@ -187,10 +252,15 @@ func newTypeResolver() *typeResolver {
 		typeTagToSerializers: map[string]Serializer{},
 		typeToSerializers:    map[reflect.Type]Serializer{},
 		typeIdToType:         map[int16]reflect.Type{},
+		extIdToType:          map[int16]reflect.Type{},
+		implementations:      map[reflect.Type]map[reflect.Type]reflect.Type{},
+		valueImplementations: map[reflect.Type]bool{},
 		typeToTypeInfo:       map[reflect.Type]string{},
 		typeInfoToType:       map[string]reflect.Type{},
 		dynamicStringToId:    map[string]int16{},
 		dynamicIdToString:    map[int16]string{},
+		encodedMetaStrings:   map[string]*metaStringBytes{},
+		writtenTypeDefs:      map[reflect.Type]int32{},
 	}
 	// base type info for encode/decode types.
 	// composite types info will be constructed dynamically.
@@ -202,16 +272,33 @@ func newTypeResolver() *typeResolver {
 		int32Type,
 		intType,
 		int64Type,
+		uint16Type,
+		uint32Type,
+		uint64Type,
+		uintType,
+		uintptrType,
 		float32Type,
 		float64Type,
+		complex64Type,
+		complex128Type,
 		stringType,
 		dateType,
 		timestampType,
+		timeOfDayType,
+		durationType,
+		intervalMonthsType,
+		intervalDayType,
 		interfaceType,
-		genericSetType, // FIXME set should be a generic type
+		genericSetType,
+		emptyStructType, // values of sets such as `map[string]struct{}`
+		decimalType,
+		bigIntPtrType,
+		bigRatPtrType,
+		bigFloatPtrType,
 	} {
-		r.typeInfoToType[t.String()] = t
-		r.typeToTypeInfo[t] = t.String()
+		typeInfo, _ := r.encodeType(t)
+		r.typeInfoToType[typeInfo] = t
+		r.typeToTypeInfo[t] = typeInfo
 	}
 	r.initialize()
 	return r
@@ -231,6 +318,10 @@ func (r *typeResolver) initialize() {
 		{int64SliceType, int64SliceSerializer{}},
 		{float32SliceType, float32SliceSerializer{}},
 		{float64SliceType, float64SliceSerializer{}},
+		{uint16SliceType, uint16SliceSerializer{}},
+		{uint32SliceType, uint32SliceSerializer{}},
+		{uint64SliceType, uint64SliceSerializer{}},
+		{uintSliceType, uintSliceSerializer{}},
 		{interfaceSliceType, sliceSerializer{}},
 		{interfaceMapType, mapSerializer{}},
 		{boolType, boolSerializer{}},
@@ -240,17 +331,102 @@ func (r *typeResolver) initialize() {
 		{int32Type, int32Serializer{}},
 		{int64Type, int64Serializer{}},
 		{intType, intSerializer{}},
+		{uint16Type, uint16Serializer{}},
+		{uint32Type, uint32Serializer{}},
+		{uint64Type, uint64Serializer{}},
+		{uintType, uintSerializer{}},
+		{uintptrType, uintSerializer{}},
 		{float32Type, float32Serializer{}},
 		{float64Type, float64Serializer{}},
+		{complex64Type, complex64Serializer{}},
+		{complex128Type, complex128Serializer{}},
 		{dateType, dateSerializer{}},
 		{timestampType, timeSerializer{}},
+		{timeOfDayType, timeOfDaySerializer{}},
+		{durationType, durationSerializer{}},
+		{intervalMonthsType, intervalMonthsSerializer{}},
+		{intervalDayType, intervalDayTimeSerializer{}},
 		{genericSetType, setSerializer{}},
+		{decimalType, decimalSerializer{}},
+		{bigIntPtrType, bigIntSerializer{}},
+		{bigRatPtrType, bigRatSerializer{}},
+		{bigFloatPtrType, bigFloatSerializer{}},
 	}
 	for _, elem := range serializers {
 		if err := r.RegisterSerializer(elem.Type, elem.Serializer); err != nil {
 			panic(fmt.Errorf("impossible error: %s", err))
 		}
 	}
+	r.typeIdToType[DATE64] = dateType
+	r.typeIdToType[TIME32] = timeOfDayType
+	r.typeIdToType[FURY_VAR_INT32] = int32Type
+	r.typeIdToType[FURY_VAR_INT64] = int64Type
+}
+
+// intSerializers returns the serializers of int32/int64/int for `encoding`.
+func intSerializers(encoding IntEncoding) map[reflect.Type]Serializer {
+	if encoding == INT_ENCODING_VARINT {
+		return map[reflect.Type]Serializer{
+			int32Type: varInt32Serializer{}, int64Type: varInt64Serializer{}, intType: varIntSerializer{},
+		}
+	}
+	return map[reflect.Type]Serializer{
+		int32Type: int32Serializer{}, int64Type: int64Serializer{}, intType: intSerializer{},
+	}
+}
+
+// canReadInto returns whether a value of `type_` in the data can be read into a value of `valueType`. Besides
+// assignable types, generic lists, maps and sets such as the ones written by peers can be read into typed ones, and
+// values of basic types can be read into named types of them.
+func canReadInto(type_ reflect.Type, valueType reflect.Type) bool {
+	if type_.AssignableTo(valueType) {
+		return true
+	}
+	if type_.Kind() != valueType.Kind() {
+		return false
+	}
+	switch type_ {
+	case interfaceSliceType, interfaceMapType, genericSetType:
+		return true
+	}
+	return basicTypes[type_.Kind()] == type_
+}
+
+// intReadSerializer returns the serializer to read an int32/int64/int value of `type_` by the type id in the data,
+// which decides the int encoding of the writer regardless of the int encoding of the reader.
+func intReadSerializer(typeId int16, type_ reflect.Type) (Serializer, bool) {
+	if typeId < 0 {
+		typeId = -typeId
+	}
+	var encoding IntEncoding
+	switch typeId {
+	case INT32, INT64:
+		encoding = INT_ENCODING_FIXED
+	case FURY_VAR_INT32, FURY_VAR_INT64:
+		encoding = INT_ENCODING_VARINT
+	default:
+		return nil, false
+	}
+	basicType, ok := basicTypes[type_.Kind()]
+	if !ok {
+		return nil, false
+	}
+	serializer, ok := intSerializers(encoding)[basicType]
+	if !ok || serializer.TypeId() != typeId && serializer.TypeId() != -typeId {
+		return nil, false
+	}
+	if basicType != type_ {
+		return &namedTypeSerializer{serializer}, true
+	}
+	return serializer, true
+}
+
+// setIntEncoding replaces the serializers of int32/int64/int by the serializers of `encoding`, which must be
+// called before serializers of other types are created.
+func (r *typeResolver) setIntEncoding(encoding IntEncoding) {
+	for type_, serializer := range intSerializers(encoding) {
+		r.typeToSerializers[type_] = serializer
+	}
 }
 
 func (r *typeResolver) RegisterSerializer(type_ reflect.Type, s Serializer) error {
@@ -274,6 +450,9 @@ func (r *typeResolver) RegisterTypeTag(type_ reflect.Type, tag string) error {
 	if prev, ok := r.typeToSerializers[type_]; ok {
 		return fmt.Errorf("type %s already has a serializer %s registered", type_, prev)
 	}
+	if _, ok := r.typeTagToSerializers[tag]; ok {
+		return fmt.Errorf("tag %s has been registered by another type", tag)
+	}
 	serializer := &structSerializer{type_: type_, typeTag: tag}
 	r.typeToSerializers[type_] = serializer
 	// multiple struct with same name defined inside function will have same `type_.String()`, but they are
@@ -292,9 +471,131 @@ func (r *typeResolver) RegisterTypeTag(type_ reflect.Type, tag string) error {
 	return nil
 }
 
+// RegisterEnum registers `type_` as an enum of `tag`, whose values are written as ordinals, or as names if
+// `byName` is true.
+func (r *typeResolver) RegisterEnum(type_ reflect.Type, tag string, names []string, byName bool) error {
+	if prev, ok := r.typeToSerializers[type_]; ok {
+		return fmt.Errorf("type %s already has a serializer %s registered", type_, prev)
+	}
+	if prev, ok := r.typeTagToSerializers[tag]; ok {
+		return fmt.Errorf("tag %s has been registered by serializer %s", tag, prev)
+	}
+	serializer, err := newEnumSerializer(type_, tag, names, byName)
+	if err != nil {
+		return err
+	}
+	r.typeToSerializers[type_] = serializer
+	r.typeTagToSerializers[tag] = serializer
+	r.typeToTypeInfo[type_] = "@" + tag
+	r.typeInfoToType["@"+tag] = type_
+	return nil
+}
+
+// RegisterImplementations registers `impls` as the implementations of interface `iface`. Every implementation is
+// a tagged struct or a pointer to it, which decides whether values of the struct are read into `iface` as values
+// or pointers.
+func (r *typeResolver) RegisterImplementations(iface reflect.Type, impls []reflect.Type) error {
+	if iface.Kind() != reflect.Interface {
+		return fmt.Errorf("%s is not an interface type", iface)
+	}
+	implementations := r.implementations[iface]
+	if implementations == nil {
+		implementations = map[reflect.Type]reflect.Type{}
+		r.implementations[iface] = implementations
+	}
+	for _, impl := range impls {
+		structType := impl
+		if impl.Kind() == reflect.Ptr {
+			structType = impl.Elem()
+		}
+		if s, ok := r.typeToSerializers[structType].(*structSerializer); !ok || s.typeTag == "" {
+			return fmt.Errorf("implementation %s of %s must be a struct registered by RegisterTagType", impl, iface)
+		}
+		if !impl.Implements(iface) {
+			return fmt.Errorf("%s doesn't implement %s", impl, iface)
+		}
+		if prev, ok := implementations[structType]; ok && prev != impl {
+			return fmt.Errorf("implementation %s of %s has been registered as %s", impl, iface, prev)
+		}
+		implementations[structType] = impl
+		if impl == structType {
+			r.valueImplementations[structType] = true
+		}
+	}
+	return nil
+}
+
+// isValueImplementation returns whether the struct of a type tag should be read into `value` as a value of
+// `structType`, rather than a pointer to it.
+func (r *typeResolver) isValueImplementation(structType reflect.Type, value reflect.Value) bool {
+	switch value.Kind() {
+	case reflect.Struct:
+		return value.Type() == structType
+	case reflect.Interface:
+		if impl, ok := r.implementations[value.Type()][structType]; ok {
+			return impl == structType
+		}
+		return r.valueImplementations[structType] && structType.AssignableTo(value.Type())
+	}
+	return false
+}
+
+// RegisterType registers the golang type info of `type_`, which is needed to deserialize values of types which
+// aren't builtin into interface values, since the type can only be known by the type info in the data.
+func (r *typeResolver) RegisterType(type_ reflect.Type) error {
+	if isMarshaller(type_) {
+		serializer, err := r.getSerializerByType(type_)
+		if err != nil {
+			return err
+		}
+		if s, ok := serializer.(*marshallerSerializer); ok {
+			if err := r.RegisterExt(s.extId, type_); err != nil {
+				return err
+			}
+		}
+	}
+	typeInfo, err := r.encodeType(type_)
+	if err != nil {
+		return err
+	}
+	if prev, ok := r.typeInfoToType[typeInfo]; ok && prev != type_ {
+		return fmt.Errorf("type %s has same type info %s with registered type %s", type_, typeInfo, prev)
+	}
+	r.typeInfoToType[typeInfo] = type_
+	r.typeToTypeInfo[type_] = typeInfo
+	return nil
+}
+
+// RegisterExt registers `type_` as the type of values of `extId` which are deserialized into interface values.
+// The ext id of a Marshaller type is registered for its non-pointer type when its serializer is created, and can be
+// registered again for the pointer type.
 func (r *typeResolver) RegisterExt(extId int16, type_ reflect.Type) error {
-	// Registering type is necessary, otherwise we may don't have the symbols of corresponding type when deserializing.
-	panic("not supported")
+	if prev, ok := r.extIdToType[extId]; ok && indirectType(prev) != indirectType(type_) {
+		return fmt.Errorf("type %s with ext id %d has been registered by type %s", type_, extId, prev)
+	}
+	r.extIdToType[extId] = type_
+	return nil
+}
+
+// readExtType reads the ext id of a Marshaller type. Values of concrete types can only be read from data of
+// their own ext id, the registered type of the ext id is returned for interface values.
+func (r *typeResolver) readExtType(buffer *ByteBuffer, value reflect.Value) (reflect.Type, error) {
+	extId := buffer.ReadInt16()
+	if value.Kind() != reflect.Interface {
+		serializer, err := r.getSerializerByType(value.Type())
+		if err != nil {
+			return nil, err
+		}
+		if s, ok := serializer.(*marshallerSerializer); !ok || s.extId != extId {
+			return nil, invalidDataError(buffer, "value of ext id %d can't be read into %s", extId, value.Type())
+		}
+		return value.Type(), nil
+	}
+	type_, ok := r.extIdToType[extId]
+	if !ok {
+		return nil, fmt.Errorf("type of ext id %d is not registered", extId)
+	}
+	return type_, nil
 }
 
 func (r *typeResolver) getSerializerByType(type_ reflect.Type) (Serializer, error) {
@@ -319,6 +620,12 @@ func (r *typeResolver) getSerializerByTypeTag(typeTag string) (Serializer, error
 }
 
 func (r *typeResolver) createSerializer(type_ reflect.Type) (s Serializer, err error) {
+	if isMarshaller(type_) {
+		return r.createMarshallerSerializer(type_)
+	}
+	if type_.Kind() != reflect.Ptr && type_.Implements(serializerFactoryType) {
+		return reflect.Zero(type_).Interface().(serializerFactory).newSerializer(r)
+	}
 	kind := type_.Kind()
 	switch kind {
 	case reflect.Ptr:
@@ -329,6 +636,10 @@ func (r *typeResolver) createSerializer(type_ reflect.Type) (s Serializer, err e
 		if err != nil {
 			return nil, err
 		}
+		if _, ok := valueSerializer.(*structSerializer); ok {
+			// pointer to tagged struct has been registered, so this is a pointer to an untagged struct.
+			return &ptrToStructSerializer{type_: type_, structSerializer: structSerializer{type_: type_.Elem()}}, nil
+		}
 		return &ptrToValueSerializer{valueSerializer}, nil
 	case reflect.Slice:
 		elem := type_.Elem()
@@ -361,6 +672,9 @@ func (r *typeResolver) createSerializer(type_ reflect.Type) (s Serializer, err e
 			}, nil
 		}
 	case reflect.Map:
+		if isSetType(type_) {
+			return newSetConcreteValueSerializer(r, type_)
+		}
 		hasKeySerializer, hasValueSerializer := !isDynamicType(type_.Key()), !isDynamicType(type_.Elem())
 		if hasKeySerializer || hasValueSerializer {
 			var keySerializer, valueSerializer Serializer
@@ -386,10 +700,81 @@ func (r *typeResolver) createSerializer(type_ reflect.Type) (s Serializer, err e
 		} else {
 			return mapSerializer{}, nil
 		}
+	case reflect.Struct:
+		// struct which isn't registered by tag, it can only be serialized in golang.
+		return &structSerializer{type_: type_}, nil
+	}
+	if basicType, ok := basicTypes[kind]; ok && basicType != type_ {
+		// named type such as `type MyInt int32`
+		basicSerializer, err := r.getSerializerByType(basicType)
+		if err != nil {
+			return nil, err
+		}
+		return &namedTypeSerializer{basicSerializer}, nil
 	}
 	return nil, fmt.Errorf("type %s not supported", type_.String())
 }
 
+func (r *typeResolver) createMarshallerSerializer(type_ reflect.Type) (Serializer, error) {
+	valueType := indirectType(type_)
+	extId := reflect.New(valueType).Interface().(Marshaller).ExtId()
+	if prev, ok := r.extIdToType[extId]; !ok {
+		r.extIdToType[extId] = valueType
+	} else if indirectType(prev) != valueType {
+		return nil, fmt.Errorf("type %s has same ext id %d with type %s", type_, extId, prev)
+	}
+	return &marshallerSerializer{extId: extId, isPtr: type_.Kind() == reflect.Ptr}, nil
+}
+
+// serializerFactory is implemented by generic types such as Set[T] and List[T], whose serializers are created
+// for their type parameters.
+type serializerFactory interface {
+	newSerializer(r *typeResolver) (Serializer, error)
+}
+
+var serializerFactoryType = reflect.TypeOf((*serializerFactory)(nil)).Elem()
+
+// getElemSerializer returns the serializer of elements of `type_` and whether their references are tracked. The
+// serializer is nil if the elements are polymorphic, so it's decided by the value of every element.
+func (r *typeResolver) getElemSerializer(type_ reflect.Type) (Serializer, bool, error) {
+	if isDynamicType(type_) {
+		return nil, true, nil
+	}
+	serializer, err := r.getSerializerByType(type_)
+	if err != nil {
+		return nil, false, err
+	}
+	return serializer, nullable(type_), nil
+}
+
+// indirectType returns the element type of a pointer type, or the type itself otherwise.
+func indirectType(type_ reflect.Type) reflect.Type {
+	if type_.Kind() == reflect.Ptr {
+		return type_.Elem()
+	}
+	return type_
+}
+
+var basicTypes = map[reflect.Kind]reflect.Type{
+	reflect.Bool:       boolType,
+	reflect.Int8:       int8Type,
+	reflect.Int16:      int16Type,
+	reflect.Int32:      int32Type,
+	reflect.Int64:      int64Type,
+	reflect.Int:        intType,
+	reflect.Uint8:      byteType,
+	reflect.Uint16:     uint16Type,
+	reflect.Uint32:     uint32Type,
+	reflect.Uint64:     uint64Type,
+	reflect.Uint:       uintType,
+	reflect.Uintptr:    uintptrType,
+	reflect.Float32:    float32Type,
+	reflect.Float64:    float64Type,
+	reflect.Complex64:  complex64Type,
+	reflect.Complex128: complex128Type,
+	reflect.String:     stringType,
+}
+
 func isDynamicType(type_ reflect.Type) bool {
 	return type_.Kind() == reflect.Interface || (type_.Kind() == reflect.Ptr && (type_.Elem().Kind() == reflect.Ptr ||
 		type_.Elem().Kind() == reflect.Interface))
@@ -412,27 +797,73 @@ func (r *typeResolver) writeType(buffer *ByteBuffer, type_ reflect.Type) error {
 	}
 }
 
-func (r *typeResolver) readType(buffer *ByteBuffer) (reflect.Type, error) {
-	metaString, err := r.readMetaString(buffer)
-	if err != nil {
-		return nil, err
+// getTypeByTypeInfo returns the type of the golang type info `metaString`. Arrays in it can't have more than
+// `maxArrayElems` elements including the elements of nested arrays.
+func (r *typeResolver) getTypeByTypeInfo(metaString string, maxArrayElems int) (reflect.Type, error) {
+	if type_, ok := r.typeInfoToType[metaString]; ok {
+		if err := r.checkTypeName(metaString, type_); err != nil {
+			return nil, err
+		}
+		return type_, nil
 	}
-	type_, ok := r.typeInfoToType[metaString]
-	if !ok {
-		type_, _, err = r.decodeType(metaString)
-		if err != nil {
+	if strings.HasPrefix(metaString, "@") || strings.HasPrefix(metaString, "*@") {
+		tag := metaString[strings.Index(metaString, "@")+1:]
+		if err := r.checkType(tag); err != nil {
 			return nil, err
-		} else {
-			r.typeInfoToType[metaString] = type_
 		}
+		return nil, &unregisteredTagError{tag: tag}
+	}
+	// composite types are checked as a whole, and by the tags and named types in them.
+	if err := r.checkType(metaString); err != nil {
+		return nil, err
+	}
+	type_, _, err := r.decodeType(metaString, maxArrayElems)
+	if err != nil {
+		return nil, err
+	}
+	if r.typeChecker == nil {
+		// with a type checker, composite types are decoded every time, so the tags and named types in them are
+		// always checked by the latest lists of the checker.
+		r.typeInfoToType[metaString] = type_
 	}
 	return type_, nil
 }
 
+// isPredeclaredType returns whether `type_` is a predeclared type such as `uint32`, which is always allowed.
+func isPredeclaredType(type_ reflect.Type) bool {
+	return type_.PkgPath() == "" && type_.Name() != ""
+}
+
+// checkTypeName checks the tag or the named type `name` resolved to `type_` when decoding a type info.
+func (r *typeResolver) checkTypeName(name string, type_ reflect.Type) error {
+	switch {
+	case strings.HasPrefix(name, "@"):
+		return r.checkType(name[len("@"):])
+	case strings.HasPrefix(name, "*@"):
+		return r.checkType(name[len("*@"):])
+	case isPredeclaredType(type_):
+		return nil
+	}
+	return r.checkType(name)
+}
+
+// encodeType returns the golang type info of `type_`. Named types are encoded by their names qualified by package
+// paths, such as `github.com/apache/fury/go/fury.Date` and `example.com/model.Pair[int,example.com/model.Event]`,
+// so same-named types of different packages have different type infos. Composite types are encoded by the grammar:
+//
+//	type = "*" type | "[]" type | "[" length "]" type | "map[" type "]" type | "@" tag | name
 func (r *typeResolver) encodeType(type_ reflect.Type) (string, error) {
 	if info, ok := r.typeToTypeInfo[type_]; ok {
 		return info, nil
 	}
+	if type_.Name() != "" {
+		// named types such as `type IDs []int32` are encoded by name instead of underlying type.
+		if type_.PkgPath() == "" {
+			// predeclared types such as `int32` and `error`.
+			return type_.Name(), nil
+		}
+		return type_.PkgPath() + "." + type_.Name(), nil
+	}
 	switch kind := type_.Kind(); kind {
 	case reflect.Ptr, reflect.Array, reflect.Slice, reflect.Map:
 		if elemTypeStr, err := r.encodeType(type_.Elem()); err != nil {
@@ -456,68 +887,103 @@ func (r *typeResolver) encodeType(type_ reflect.Type) (string, error) {
 	return type_.String(), nil
 }
 
-func (r *typeResolver) decodeType(typeStr string) (reflect.Type, string, error) {
+// decodeType decodes the type info at the beginning of `typeStr` by the grammar of `encodeType`, and returns the
+// type and the decoded prefix of `typeStr`. Named types can't be created by reflection, so they must be registered.
+// Every tag and named type in `typeStr` is checked by the type checker. An array with more than `maxArrayElems`
+// elements including the elements of nested arrays is rejected by *arrayLengthError, since its length comes from
+// the data and the array is allocated as a whole.
+func (r *typeResolver) decodeType(typeStr string, maxArrayElems int) (reflect.Type, string, error) {
 	if type_, ok := r.typeInfoToType[typeStr]; ok {
+		if err := r.checkTypeName(typeStr, type_); err != nil {
+			return nil, "", err
+		}
 		return type_, typeStr, nil
 	}
-	if strings.HasPrefix(typeStr, "*") { // ptr
-		subStr := typeStr[len("*"):]
-		type_, subStr, err := r.decodeType(subStr)
+	switch {
+	case strings.HasPrefix(typeStr, "*"): // ptr
+		type_, elemStr, err := r.decodeType(typeStr[len("*"):], maxArrayElems)
 		if err != nil {
 			return nil, "", err
-		} else {
-			return reflect.PtrTo(type_), "*" + subStr, nil
 		}
-	} else if strings.HasPrefix(typeStr, "[]") { // slice
-		subStr := typeStr[len("[]"):]
-		type_, subStr, err := r.decodeType(subStr)
+		return reflect.PtrTo(type_), "*" + elemStr, nil
+	case strings.HasPrefix(typeStr, "[]"): // slice
+		type_, elemStr, err := r.decodeType(typeStr[len("[]"):], maxArrayElems)
 		if err != nil {
 			return nil, "", err
-		} else {
-			return reflect.SliceOf(type_), "[]" + subStr, nil
 		}
-	} else if strings.HasPrefix(typeStr, "[") { // array
-		arrTypeRegex, _ := regexp.Compile(`\[([0-9]+)]`)
-		idx := arrTypeRegex.FindStringSubmatchIndex(typeStr)
-		if idx == nil {
-			return nil, "", fmt.Errorf("unparseable type %s", typeStr)
+		return reflect.SliceOf(type_), "[]" + elemStr, nil
+	case strings.HasPrefix(typeStr, "["): // array
+		end := strings.IndexByte(typeStr, ']')
+		if end < 0 {
+			return nil, "", fmt.Errorf("unparseable array type %s", typeStr)
 		}
-		lenStr := typeStr[idx[2]:idx[3]]
-		if length, err := strconv.Atoi(lenStr); err != nil {
+		length, err := strconv.Atoi(typeStr[len("["):end])
+		if err != nil || length < 0 {
+			return nil, "", fmt.Errorf("unparseable array type %s", typeStr)
+		}
+		type_, elemStr, err := r.decodeType(typeStr[end+len("]"):], maxArrayElems)
+		if err != nil {
 			return nil, "", err
-		} else {
-			subStr := typeStr[idx[1]:]
-			type_, elemStr, err := r.decodeType(subStr)
-			if err != nil {
-				return nil, "", err
-			} else {
-				return reflect.ArrayOf(length, type_), typeStr[idx[0]:idx[1]] + elemStr, nil
-			}
 		}
-	} else if strings.HasPrefix(typeStr, "map[") {
-		subStr := typeStr[len("map["):]
-		keyType, keyStr, err := r.decodeType(subStr)
+		if length > maxArrayElems/arrayElems(type_) {
+			return nil, "", &arrayLengthError{typeStr: typeStr[:end+len("]")] + elemStr, max: maxArrayElems}
+		}
+		return reflect.ArrayOf(length, type_), typeStr[:end+len("]")] + elemStr, nil
+	case strings.HasPrefix(typeStr, "map["):
+		keyType, keyStr, err := r.decodeType(typeStr[len("map["):], maxArrayElems)
 		if err != nil {
-			return nil, "", fmt.Errorf("unparseable map type: %s : %s", typeStr, err)
-		} else {
-			subStr := typeStr[len("map[")+len(keyStr)+len("]"):]
-			valueType, valueStr, err := r.decodeType(subStr)
-			if err != nil {
-				return nil, "", fmt.Errorf("unparseable map value type: %s : %s", subStr, err)
-			} else {
-				return reflect.MapOf(keyType, valueType), "map[" + keyStr + "]" + valueStr, nil
-			}
+			return nil, "", fmt.Errorf("unparseable map key type: %s : %w", typeStr, err)
 		}
-	} else {
-		if idx := strings.Index(typeStr, "]"); idx >= 0 {
-			return r.decodeType(typeStr[:idx])
+		subStr := typeStr[len("map[")+len(keyStr):]
+		if !strings.HasPrefix(subStr, "]") || !keyType.Comparable() {
+			return nil, "", fmt.Errorf("unparseable map type %s", typeStr)
+		}
+		valueType, valueStr, err := r.decodeType(subStr[len("]"):], maxArrayElems)
+		if err != nil {
+			return nil, "", fmt.Errorf("unparseable map value type: %s : %w", subStr, err)
 		}
-		if t, ok := r.typeInfoToType[typeStr]; !ok {
-			return nil, "", fmt.Errorf("type %s not supported", typeStr)
+		return reflect.MapOf(keyType, valueType), "map[" + keyStr + "]" + valueStr, nil
+	default:
+		name := typeStr[:nameLength(typeStr)]
+		if t, ok := r.typeInfoToType[name]; !ok {
+			return nil, "", fmt.Errorf("type %s not supported", name)
+		} else if err := r.checkTypeName(name, t); err != nil {
+			return nil, "", err
 		} else {
-			return t, typeStr, nil
+			return t, name, nil
+		}
+	}
+}
+
+// arrayElems returns the number of elements of `type_` including the elements of nested arrays, empty arrays are
+// counted as one element since their lengths are still written. It's 1 if `type_` isn't an array.
+func arrayElems(type_ reflect.Type) int {
+	elems := 1
+	for ; type_.Kind() == reflect.Array; type_ = type_.Elem() {
+		if type_.Len() > 1 {
+			elems *= type_.Len()
+		}
+	}
+	return elems
+}
+
+// nameLength returns the length of the type name at the beginning of `typeStr`, which ends before the first
+// unbalanced `]` such as the end of a map key. Brackets of type arguments such as `Pair[[]int,string]` and braces
+// of types such as `struct { A []int }` are balanced.
+func nameLength(typeStr string) int {
+	depth := 0
+	for i := 0; i < len(typeStr); i++ {
+		switch typeStr[i] {
+		case '[', '{', '(':
+			depth++
+		case ']', '}', ')':
+			if depth == 0 {
+				return i
+			}
+			depth--
 		}
 	}
+	return len(typeStr)
 }
 
 func (r *typeResolver) writeTypeTag(buffer *ByteBuffer, typeTag string) error {
@@ -528,12 +994,38 @@ func (r *typeResolver) writeTypeTag(buffer *ByteBuffer, typeTag string) error {
 	}
 }
 
-func (r *typeResolver) readTypeByReadTag(buffer *ByteBuffer) (reflect.Type, error) {
+// readTypeByReadTag reads a type tag and returns the registered type. The tag is checked by the type checker
+// unless it's the tag of the type of `value`, which is chosen by the caller.
+func (r *typeResolver) readTypeByReadTag(buffer *ByteBuffer, value reflect.Value) (reflect.Type, error) {
 	metaString, err := r.readMetaString(buffer)
 	if err != nil {
 		return nil, err
 	}
-	return r.typeTagToSerializers[metaString].(*ptrToStructSerializer).type_, err
+	serializer, ok := r.typeTagToSerializers[metaString]
+	var type_ reflect.Type
+	if !ok {
+		if err := r.checkType(metaString); err != nil {
+			return nil, err
+		}
+		return nil, &unregisteredTagError{tag: metaString}
+	} else if s, ok := serializer.(*enumSerializer); ok {
+		type_ = s.type_
+	} else {
+		type_ = serializer.(*ptrToStructSerializer).type_
+	}
+	if type_ != value.Type() && (type_.Kind() != reflect.Ptr || type_.Elem() != value.Type()) {
+		if err := r.checkType(metaString); err != nil {
+			return nil, err
+		}
+	}
+	return type_, nil
+}
+
+func (r *typeResolver) checkType(name string) error {
+	if r.typeChecker != nil && !r.typeChecker.CheckType(name) {
+		return &DisallowedTypeError{Name: name}
+	}
+	return nil
 }
 
 func (r *typeResolver) readTypeInfo(buffer *ByteBuffer) (string, error) {
@@ -548,52 +1040,98 @@ func (r *typeResolver) getTypeById(id int16) (reflect.Type, error) {
 	return type_, nil
 }
 
+// metaStringBytes is the encoded bytes of a meta string and its hash, whose lowest byte is the encoding.
+type metaStringBytes struct {
+	data     []byte
+	encoding meta.Encoding
+	hash     int64
+}
+
+func newMetaStringBytes(metaString meta.MetaString) *metaStringBytes {
+	data := metaString.GetEncodedBytes()
+	// the same as `MetaStringBytes` of java.
+	h1, _ := murmurHash3_x64_128(data, murmurHashSeed)
+	hash := int64(h1)
+	if hash < 0 {
+		hash = -hash
+	}
+	if hash == 0 {
+		hash += 256
+	}
+	hash = hash&^0xff | int64(metaString.GetEncoding())
+	return &metaStringBytes{data: data, encoding: metaString.GetEncoding(), hash: hash}
+}
+
+// writeMetaString writes `str` compressed by the meta string encoding which takes the least bytes. The encoding is
+// written in a byte for small strings, and in the lowest byte of the hash for others.
 func (r *typeResolver) writeMetaString(buffer *ByteBuffer, str string) error {
-	if id, ok := r.dynamicStringToId[str]; !ok {
-		dynamicStringId := r.dynamicStringId
-		r.dynamicStringId += 1
-		r.dynamicStringToId[str] = dynamicStringId
-		length := len(str)
-		buffer.WriteVarInt32(int32(length << 1))
-		if length <= SMALL_STRING_THRESHOLD {
-			buffer.WriteByte_(uint8(meta.UTF_8))
-		} else {
-			// TODO this hash should be unique, since we don't compare data equality for performance
-			h := fnv.New64a()
-			if _, err := h.Write([]byte(str)); err != nil {
-				return err
-			}
-			hash := int64(h.Sum64() & 0xffffffffffffff00)
-			buffer.WriteInt64(hash)
-		}
+	if id, ok := r.dynamicStringToId[str]; ok {
+		buffer.WriteVarInt32(int32(((id + 1) << 1) | 1))
+		return nil
+	}
+	metaStr, ok := r.encodedMetaStrings[str]
+	if !ok {
 		if len(str) > MaxInt16 {
 			return fmt.Errorf("too long string: %s", str)
 		}
-		buffer.WriteBinary(unsafeGetBytes(str))
+		metaString, err := typeMetaStringEncoder.Encode(str)
+		if err != nil {
+			return err
+		}
+		metaStr = newMetaStringBytes(metaString)
+		r.encodedMetaStrings[str] = metaStr
+	}
+	dynamicStringId := r.dynamicStringId
+	r.dynamicStringId += 1
+	r.dynamicStringToId[str] = dynamicStringId
+	length := len(metaStr.data)
+	buffer.WriteVarInt32(int32(length << 1))
+	if length <= SMALL_STRING_THRESHOLD {
+		buffer.WriteByte_(uint8(metaStr.encoding))
 	} else {
-		buffer.WriteVarInt32(int32(((id + 1) << 1) | 1))
+		buffer.WriteInt64(metaStr.hash)
 	}
+	buffer.WriteBinary(metaStr.data)
 	return nil
 }
 
 func (r *typeResolver) readMetaString(buffer *ByteBuffer) (string, error) {
 	header := buffer.ReadVarInt32()
 	var length = int(header >> 1)
+	if length < 0 || length > MaxInt16 {
+		return "", invalidDataError(buffer, "invalid meta string header %d", header)
+	}
 	if header&0b1 == 0 {
+		var encoding meta.Encoding
 		if length <= SMALL_STRING_THRESHOLD {
-			buffer.ReadByte_()
+			encoding = meta.Encoding(buffer.ReadByte_())
 		} else {
 			// TODO support use computed hash
-			buffer.ReadInt64()
+			encoding = meta.Encoding(buffer.ReadInt64() & 0xff)
+		}
+		str, err := decodeMetaString(typeMetaStringDecoder, buffer.ReadBinary(length), encoding)
+		if err != nil {
+			return "", invalidDataError(buffer, "invalid meta string: %s", err)
 		}
-		str := string(buffer.ReadBinary(length))
 		dynamicStringId := r.dynamicStringId
 		r.dynamicStringId += 1
 		r.dynamicIdToString[dynamicStringId] = str
 		return str, nil
 	} else {
-		return r.dynamicIdToString[int16(length-1)], nil
+		str, ok := r.dynamicIdToString[int16(length-1)]
+		if !ok {
+			return "", invalidDataError(buffer, "meta string id %d doesn't exist", length-1)
+		}
+		return str, nil
+	}
+}
+
+// decodeMetaString decodes the meta string bytes of `encoding`, which may be empty.
+func decodeMetaString(decoder *meta.Decoder, data []byte, encoding meta.Encoding) (string, error) {
+	if len(data) == 0 {
+		return "", nil
 	}
+	return decoder.Decode(data, encoding)
 }
 
 func (r *typeResolver) resetWrite() {
@@ -602,6 +1140,9 @@ func (r *typeResolver) resetWrite() {
 		r.dynamicIdToString = map[int16]string{}
 		r.dynamicStringId = 0
 	}
+	if len(r.writtenTypeDefs) > 0 {
+		r.writtenTypeDefs = map[reflect.Type]int32{}
+	}
 }
 
 func (r *typeResolver) resetRead() {
@@ -610,6 +1151,7 @@ func (r *typeResolver) resetRead() {
 		r.dynamicIdToString = map[int16]string{}
 		r.dynamicStringId = 0
 	}
+	r.readTypeDefs = r.readTypeDefs[:0]
 }
 
 func computeStringHash(str string) int32 {
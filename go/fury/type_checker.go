@@ -0,0 +1,144 @@
+// Licensed to the Apache Software Foundation (ASF) under one
+// or more contributor license agreements.  See the NOTICE file
+// distributed with this work for additional information
+// regarding copyright ownership.  The ASF licenses this file
+// to you under the Apache License, Version 2.0 (the
+// "License"); you may not use this file except in compliance
+// with the License.  You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package fury
+
+import (
+	"log"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// TypeChecker decides whether a type read from the data can be deserialized, which prevents untrusted data
+// from instantiating unexpected types. A TypeChecker is shared by all Fury instances created by the same
+// Config, so it must be safe for concurrent use.
+type TypeChecker interface {
+	// CheckType returns whether the type named `name` can be deserialized. `name` is the type tag for registered
+	// structs, or the golang type info for other types such as `[]*@example.Foo` and `map[string]int32`. Named
+	// types are qualified by their package paths, such as `example.com/model.Event`.
+	// The type may not be created yet when it's checked.
+	CheckType(name string) bool
+}
+
+// CheckLevel is the level of AllowListChecker.
+type CheckLevel = uint8
+
+const (
+	// CHECK_DISABLE allows all types except the disallowed ones.
+	CHECK_DISABLE CheckLevel = iota
+	// CHECK_WARN allows all types except the disallowed ones, and logs a warning for types not in the allow list.
+	CHECK_WARN
+	// CHECK_STRICT only allows types in the allow list which aren't disallowed.
+	CHECK_STRICT
+)
+
+// maxWarnedTypes is the max number of names warned about by an AllowListChecker. The names come from the data, so
+// they aren't recorded any more after the limit, and later names aren't warned about.
+const maxWarnedTypes = 1024
+
+// AllowListChecker is a TypeChecker based on allow list and disallow list. A name in the lists can end
+// with `*` to match all names with the prefix before `*`, such as `example.*`.
+type AllowListChecker struct {
+	level CheckLevel
+	mu    sync.RWMutex
+	allow nameList
+	deny  nameList
+	// warned records the names which have been warned about in CHECK_WARN level, so every name is logged once.
+	warned      sync.Map
+	warnedCount int32
+}
+
+// NewAllowListChecker creates an AllowListChecker with empty lists.
+func NewAllowListChecker(level CheckLevel) *AllowListChecker {
+	return &AllowListChecker{level: level}
+}
+
+// Allow adds `names` to the allow list.
+func (c *AllowListChecker) Allow(names ...string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.allow.add(names)
+}
+
+// Disallow adds `names` to the disallow list, which takes precedence over the allow list.
+func (c *AllowListChecker) Disallow(names ...string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.deny.add(names)
+}
+
+func (c *AllowListChecker) CheckType(name string) bool {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	if c.deny.match(name) {
+		return false
+	}
+	if c.level == CHECK_DISABLE || c.allow.match(name) {
+		return true
+	}
+	if c.level == CHECK_WARN {
+		c.warn(name)
+		return true
+	}
+	return false
+}
+
+// warn logs a warning for `name` if it hasn't been warned about and the warned names don't exceed maxWarnedTypes.
+func (c *AllowListChecker) warn(name string) {
+	if atomic.LoadInt32(&c.warnedCount) >= maxWarnedTypes {
+		return
+	}
+	if _, loaded := c.warned.LoadOrStore(name, true); loaded {
+		return
+	}
+	log.Printf("fury: type %s is not in the allow list, it will be disallowed in strict mode", name)
+	if atomic.AddInt32(&c.warnedCount, 1) == maxWarnedTypes {
+		log.Printf("fury: %d types not in the allow list have been warned about, later ones won't be",
+			maxWarnedTypes)
+	}
+}
+
+type nameList struct {
+	names    map[string]bool
+	prefixes []string
+}
+
+func (l *nameList) add(names []string) {
+	if l.names == nil {
+		l.names = map[string]bool{}
+	}
+	for _, name := range names {
+		if strings.HasSuffix(name, "*") {
+			l.prefixes = append(l.prefixes, strings.TrimSuffix(name, "*"))
+		} else {
+			l.names[name] = true
+		}
+	}
+}
+
+func (l *nameList) match(name string) bool {
+	if l.names[name] {
+		return true
+	}
+	for _, prefix := range l.prefixes {
+		if strings.HasPrefix(name, prefix) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,120 @@
+// Licensed to the Apache Software Foundation (ASF) under one
+// or more contributor license agreements.  See the NOTICE file
+// distributed with this work for additional information
+// regarding copyright ownership.  The ASF licenses this file
+// to you under the Apache License, Version 2.0 (the
+// "License"); you may not use this file except in compliance
+// with the License.  You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package fury
+
+import (
+	"errors"
+	"fmt"
+	"github.com/stretchr/testify/require"
+	"io"
+	"log"
+	"os"
+	"testing"
+)
+
+func TestAllowListChecker(t *testing.T) {
+	checker := NewAllowListChecker(CHECK_STRICT)
+	checker.Allow("example.*", "[]int32")
+	checker.Disallow("example.Secret")
+	require.True(t, checker.CheckType("example.Foo"))
+	require.True(t, checker.CheckType("[]int32"))
+	require.False(t, checker.CheckType("[]int64"))
+	require.False(t, checker.CheckType("example.Secret"))
+
+	checker = NewAllowListChecker(CHECK_WARN)
+	checker.Disallow("example.*")
+	require.True(t, checker.CheckType("[]int64"))
+	require.True(t, checker.CheckType("[]int64"))
+	_, warned := checker.warned.Load("[]int64")
+	require.True(t, warned)
+	// names aren't recorded any more after maxWarnedTypes names.
+	log.SetOutput(io.Discard)
+	for i := 0; i < maxWarnedTypes*2; i++ {
+		require.True(t, checker.CheckType(fmt.Sprintf("[%d]int64", i)))
+	}
+	log.SetOutput(os.Stderr)
+	require.Equal(t, int32(maxWarnedTypes), checker.warnedCount)
+	_, warned = checker.warned.Load(fmt.Sprintf("[%d]int64", maxWarnedTypes))
+	require.False(t, warned)
+	require.False(t, checker.CheckType("example.Foo"))
+
+	checker = NewAllowListChecker(CHECK_DISABLE)
+	checker.Disallow("example.Foo")
+	require.True(t, checker.CheckType("example.Bar"))
+	require.False(t, checker.CheckType("example.Foo"))
+}
+
+func TestDeserializeWithTypeChecker(t *testing.T) {
+	newFury := func(checker TypeChecker, opts ...Option) *Fury {
+		fury := NewFury(append(opts, WithTypeChecker(checker))...)
+		require.Nil(t, fury.RegisterTagType("example.Bar", Bar{}))
+		require.Nil(t, fury.RegisterTagType("example.Foo", Foo{}))
+		return fury
+	}
+	foo := newFoo()
+	bytes, err := newFury(nil).Marshal(&foo)
+	require.Nil(t, err)
+
+	checker := NewAllowListChecker(CHECK_STRICT)
+	var newValue interface{}
+	err = newFury(checker).Unmarshal(bytes, &newValue)
+	require.True(t, errors.Is(err, ErrDisallowedType), err)
+	var disallowedTypeError *DisallowedTypeError
+	require.True(t, errors.As(err, &disallowedTypeError))
+	require.Equal(t, "example.Foo", disallowedTypeError.Name)
+	// concrete types are chosen by the caller and not checked.
+	var newFoo *Foo
+	require.Nil(t, newFury(checker).Unmarshal(bytes, &newFoo))
+	require.Equal(t, &foo, newFoo)
+
+	checker.Allow("example.*")
+	require.Nil(t, newFury(checker).Unmarshal(bytes, &newValue))
+	require.Equal(t, &foo, newValue)
+	checker.Disallow("example.Foo")
+	require.True(t, errors.Is(newFury(checker).Unmarshal(bytes, &newValue), ErrDisallowedType))
+	bytes, err = newFury(nil).Marshal([]interface{}{&Bar{F1: 1}})
+	require.Nil(t, err)
+	require.Nil(t, newFury(checker).Unmarshal(bytes, &newValue))
+
+	// golang type infos are checked by the whole composite type, except predeclared types.
+	checker = NewAllowListChecker(CHECK_STRICT)
+	fury := newFury(checker, WithLanguage(GO))
+	serde(t, fury, uint32(1))
+	bytes, err = fury.Marshal(map[string]uint32{"k": 1})
+	require.Nil(t, err)
+	require.True(t, errors.Is(fury.Unmarshal(bytes, &newValue), ErrDisallowedType))
+	checker.Allow("map[string]uint32")
+	require.Nil(t, fury.Unmarshal(bytes, &newValue))
+	require.Equal(t, map[string]uint32{"k": 1}, newValue)
+
+	// tags in composite types are checked too.
+	checker.Allow("example.*", "[]*@example.Foo", "map[string]*@example.Foo")
+	checker.Disallow("example.Foo")
+	for _, value := range []interface{}{[]*Foo{&foo}, map[string]*Foo{"k": &foo}} {
+		bytes, err = fury.Marshal(value)
+		require.Nil(t, err)
+		err = fury.Unmarshal(bytes, &newValue)
+		require.True(t, errors.As(err, &disallowedTypeError), err)
+		require.Equal(t, "example.Foo", disallowedTypeError.Name)
+	}
+	// tags of other types than the concrete type are checked.
+	bytes, err = fury.Marshal(&Bar{F1: 1})
+	require.Nil(t, err)
+	checker.Disallow("example.Bar")
+	require.True(t, errors.Is(fury.Unmarshal(bytes, &newFoo), ErrDisallowedType))
+}
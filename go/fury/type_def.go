@@ -0,0 +1,470 @@
+// Licensed to the Apache Software Foundation (ASF) under one
+// or more contributor license agreements.  See the NOTICE file
+// distributed with this work for additional information
+// regarding copyright ownership.  The ASF licenses this file
+// to you under the Apache License, Version 2.0 (the
+// "License"); you may not use this file except in compliance
+// with the License.  You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package fury
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"github.com/apache/fury/go/fury/meta"
+	"reflect"
+	"strings"
+)
+
+const (
+	// newTypeDefFlag is written before a type def which hasn't been written in current serialization.
+	newTypeDefFlag = 0b11111111
+	// typeDefNumClassesMask is the lowest 4 bits of type def header, which is used to record num classes.
+	typeDefNumClassesMask = 0b1111
+	// typeDefCompatibleFlag is the 5th bit of type def header, which indicates the type needs schema evolution.
+	typeDefCompatibleFlag = 0b10000
+	// fieldNameSizeThreshold is the max field name size which can be written in the 4 bits of field header.
+	fieldNameSizeThreshold = 0b1111
+)
+
+var (
+	// fieldNameEncodings is the encodings of field names, whose indexes are written in the 2 bits of field header.
+	fieldNameEncodings = []meta.Encoding{meta.UTF_8, meta.ALL_TO_LOWER_SPECIAL, meta.LOWER_UPPER_DIGIT_SPECIAL}
+	fieldNameEncoder   = meta.NewEncoder('$', '_')
+	fieldNameDecoder   = meta.NewDecoder('$', '_')
+)
+
+// encodeFieldName encodes a field name by the encoding of `fieldNameEncodings` which takes the least bytes, and
+// returns the index of the encoding.
+func encodeFieldName(name string) ([]byte, byte, error) {
+	encoding := fieldNameEncoder.ComputeEncoding(name)
+	switch {
+	case encoding == meta.LOWER_SPECIAL && strings.Contains(name, "|"):
+		// `|` escapes upper case chars in ALL_TO_LOWER_SPECIAL.
+		encoding = meta.UTF_8
+	case encoding == meta.LOWER_SPECIAL || encoding == meta.FIRST_TO_LOWER_SPECIAL:
+		// the same size as ALL_TO_LOWER_SPECIAL for names without or with only one upper case char.
+		encoding = meta.ALL_TO_LOWER_SPECIAL
+	}
+	metaString, err := fieldNameEncoder.EncodeWithEncoding(name, encoding)
+	if err != nil {
+		return nil, 0, err
+	}
+	for i, e := range fieldNameEncodings {
+		if e == encoding {
+			return metaString.GetEncodedBytes(), byte(i), nil
+		}
+	}
+	return nil, 0, fmt.Errorf("unexpected encoding %d of field name %s", encoding, name)
+}
+
+// typeDef is the fields meta of a struct written by peer, see `Type Def` in xlang serialization spec.
+type typeDef struct {
+	header int64
+	fields []*fieldDef
+}
+
+type fieldDef struct {
+	name        string
+	nullable    bool
+	trackingRef bool
+	// fieldType is the encoded field type, which is compared with `fieldInfo.typeBytes` to check whether
+	// the field can be read into local field.
+	fieldType []byte
+}
+
+// encodeTypeDef encodes the fields meta of a struct:
+// | 8 bytes meta header | num_fields | type id | field info | next field info | ... |
+// Every field info is encoded as `| header | [extra name size] | field type | field name |`.
+func encodeTypeDef(fields structFieldsInfo, referenceTracking bool) ([]byte, error) {
+	body := NewByteBuffer(nil)
+	body.WriteVarInt32(int32(len(fields)))
+	body.WriteVarInt32(FURY_TYPE_TAG)
+	for _, field := range fields {
+		if len(field.name) == 0 || len(field.name) > MaxInt16 {
+			return nil, fmt.Errorf("invalid field name %s", field.name)
+		}
+		name, encoding, err := encodeFieldName(field.name)
+		if err != nil {
+			return nil, err
+		}
+		size := len(name) - 1
+		var header byte
+		if size >= fieldNameSizeThreshold {
+			header = fieldNameSizeThreshold << 4
+		} else {
+			header = byte(size) << 4
+		}
+		header |= encoding << 2
+		if field.nullable {
+			header |= 0b10
+		}
+		if field.referencable && referenceTracking {
+			header |= 0b1
+		}
+		body.WriteByte_(header)
+		if size >= fieldNameSizeThreshold {
+			body.WriteVarInt32(int32(size - fieldNameSizeThreshold))
+		}
+		body.WriteBinary(field.typeBytes)
+		body.WriteBinary(name)
+	}
+	data := body.GetByteSlice(0, body.WriterIndex())
+	header := typeDefHash(data) | typeDefCompatibleFlag | 1
+	typeDefBytes := make([]byte, 8+len(data))
+	binary.LittleEndian.PutUint64(typeDefBytes, uint64(header))
+	copy(typeDefBytes[8:], data)
+	return typeDefBytes, nil
+}
+
+// typeDefHash returns the 56 bits MurmurHash3 of the type def body in the high bits of the meta header, the same as
+// `ClassDefEncoder` of java.
+func typeDefHash(data []byte) int64 {
+	h1, _ := murmurHash3_x64_128(data, murmurHashSeed)
+	hash := int64(h1 << 8)
+	if hash < 0 {
+		hash = -hash
+	}
+	return hash
+}
+
+// encodeFieldType encodes field type as `id << 1 | polymorphic flag`. Struct types are written as `STRUCT`,
+// element types of list/set and key/value types of map are written recursively. Enums are written as
+// `FURY_TYPE_TAG` followed by the type of their values, `STRING` for names and `UINT32` for ordinals, so they can
+// be skipped without being registered.
+func encodeFieldType(r *typeResolver, buffer *ByteBuffer, type_ reflect.Type) {
+	if type_.Kind() == reflect.Interface {
+		buffer.WriteVarInt32(int32(NA)<<1 | 1)
+		return
+	}
+	serializer, err := r.getSerializerByType(type_)
+	if err != nil {
+		// type can't be known until the value is written.
+		buffer.WriteVarInt32(int32(NA)<<1 | 1)
+		return
+	}
+	if ptr, ok := serializer.(*ptrToValueSerializer); ok {
+		if _, ok := ptr.valueSerializer.(*enumSerializer); ok {
+			serializer = ptr.valueSerializer
+		}
+	}
+	switch s := serializer.(type) {
+	case *structSerializer, *ptrToStructSerializer:
+		buffer.WriteVarInt32(int32(STRUCT) << 1)
+		return
+	case *enumSerializer:
+		buffer.WriteVarInt32(int32(FURY_TYPE_TAG) << 1)
+		if s.byName {
+			buffer.WriteVarInt32(int32(STRING) << 1)
+		} else {
+			buffer.WriteVarInt32(int32(UINT32) << 1)
+		}
+		return
+	}
+	id := serializer.TypeId()
+	if id < 0 {
+		id = -id
+	}
+	buffer.WriteVarInt32(int32(id) << 1)
+	switch id {
+	case LIST:
+		encodeElemType(r, buffer, type_, type_.Kind() == reflect.Slice || type_.Kind() == reflect.Array, false)
+	case FURY_SET:
+		encodeElemType(r, buffer, type_, type_.Kind() == reflect.Map, true)
+	case MAP:
+		encodeElemType(r, buffer, type_, type_.Kind() == reflect.Map, true)
+		encodeElemType(r, buffer, type_, type_.Kind() == reflect.Map, false)
+	}
+}
+
+// encodeStructFieldType encodes the type of a struct field. Int fields in varint encoding are written as
+// FURY_VAR_INT32/FURY_VAR_INT64, the same as the type ids of their values.
+func encodeStructFieldType(r *typeResolver, buffer *ByteBuffer, field *fieldInfo) {
+	serializer := field.serializer
+	if named, ok := serializer.(*namedTypeSerializer); ok {
+		serializer = named.basicSerializer
+	}
+	switch serializer.(type) {
+	case varInt32Serializer:
+		buffer.WriteVarInt32(int32(FURY_VAR_INT32) << 1)
+	case varInt64Serializer, varIntSerializer:
+		buffer.WriteVarInt32(int32(FURY_VAR_INT64) << 1)
+	default:
+		encodeFieldType(r, buffer, field.type_)
+	}
+}
+
+// encodeElemType encodes element type of list/set or key/value type of map. Element type is polymorphic if
+// `type_` is a custom type which doesn't expose its element type.
+func encodeElemType(r *typeResolver, buffer *ByteBuffer, type_ reflect.Type, hasElem bool, key bool) {
+	switch {
+	case !hasElem:
+		buffer.WriteVarInt32(int32(NA)<<1 | 1)
+	case key:
+		encodeFieldType(r, buffer, type_.Key())
+	default:
+		encodeFieldType(r, buffer, type_.Elem())
+	}
+}
+
+// writeTypeDef writes the type def of `type_` in streaming mode: the full type def is written the first time
+// and only its index is written for later occurrences in current serialization.
+func (r *typeResolver) writeTypeDef(buffer *ByteBuffer, type_ reflect.Type, typeDefBytes []byte) {
+	if index, ok := r.writtenTypeDefs[type_]; ok {
+		buffer.WriteVarInt32(index << 1)
+		return
+	}
+	r.writtenTypeDefs[type_] = int32(len(r.writtenTypeDefs))
+	buffer.WriteVarInt32(newTypeDefFlag)
+	buffer.WriteBinary(typeDefBytes)
+}
+
+func (r *typeResolver) readTypeDef(buffer *ByteBuffer) (*typeDef, error) {
+	flag := buffer.ReadVarInt32()
+	if flag != newTypeDefFlag {
+		index := int(flag >> 1)
+		if flag&0b1 != 0 || index >= len(r.readTypeDefs) {
+			return nil, fmt.Errorf("invalid type def index %d", flag)
+		}
+		return r.readTypeDefs[index], nil
+	}
+	header := buffer.ReadInt64()
+	if header&typeDefNumClassesMask != 1 {
+		return nil, fmt.Errorf("type def with %d classes is not supported", header&typeDefNumClassesMask)
+	}
+	start := buffer.ReaderIndex()
+	numFields := int(buffer.ReadVarInt32())
+	if numFields < 0 {
+		return nil, fmt.Errorf("invalid fields number %d", numFields)
+	}
+	if typeId := buffer.ReadVarInt32(); typeId != FURY_TYPE_TAG {
+		return nil, fmt.Errorf("type def of type id %d is not supported", typeId)
+	}
+	def := &typeDef{header: header}
+	for i := 0; i < numFields; i++ {
+		fieldHeader := buffer.ReadByte_()
+		encodingIndex := int((fieldHeader >> 2) & 0b11)
+		if encodingIndex >= len(fieldNameEncodings) {
+			return nil, fmt.Errorf("field name encoding %d is not supported", encodingIndex)
+		}
+		size := int(fieldHeader >> 4)
+		if size == fieldNameSizeThreshold {
+			size += int(buffer.ReadVarInt32())
+		}
+		typeStart := buffer.ReaderIndex()
+		skipFieldType(buffer)
+		fieldType := buffer.GetByteSlice(typeStart, buffer.ReaderIndex())
+		name, err := decodeMetaString(fieldNameDecoder, buffer.ReadBinary(size+1), fieldNameEncodings[encodingIndex])
+		if err != nil {
+			return nil, fmt.Errorf("invalid field name: %s", err)
+		}
+		def.fields = append(def.fields, &fieldDef{
+			name:        name,
+			nullable:    fieldHeader&0b10 != 0,
+			trackingRef: fieldHeader&0b1 != 0,
+			fieldType:   append([]byte(nil), fieldType...),
+		})
+	}
+	if typeDefHash(buffer.GetByteSlice(start, buffer.ReaderIndex())) != header&^0xff {
+		return nil, fmt.Errorf("type def hash is not consistent with its fields meta")
+	}
+	r.readTypeDefs = append(r.readTypeDefs, def)
+	return def, nil
+}
+
+// skipFieldType skips an encoded field type. Nested types are skipped iteratively, so deeply nested types
+// in malicious data can't overflow the stack.
+func skipFieldType(buffer *ByteBuffer) {
+	for pending := 1; pending > 0; pending-- {
+		switch TypeId(buffer.ReadVarInt32() >> 1) {
+		case LIST, FURY_SET, FURY_TYPE_TAG:
+			pending++
+		case MAP:
+			pending += 2
+		}
+	}
+}
+
+// skipField skips the value of a peer field which doesn't exist locally. Lists, sets, maps, enums and values of
+// named types are skipped by the type ids and the layout of the data, so the golang types in them don't need to
+// be known locally, such as named types which aren't registered.
+func skipField(f *Fury, buffer *ByteBuffer, field *fieldDef) error {
+	if field.trackingRef {
+		return skipReferencable(f, buffer, field.fieldType)
+	}
+	flag := buffer.ReadInt8()
+	if flag == NullFlag && field.nullable {
+		return nil
+	}
+	if flag != NotNullValueFlag {
+		return invalidDataError(buffer, "unexpected flag %d of field", flag)
+	}
+	if f.referenceTracking {
+		// see `readUntrackedData`.
+		f.refResolver.preserveStubRefId()
+		defer f.refResolver.releaseStubRefId()
+	}
+	_, err := skipData(f, buffer, field.fieldType)
+	return err
+}
+
+// skipReferencable skips a value with a ref flag, `fieldType` is its encoded type or nil if it's unknown.
+func skipReferencable(f *Fury, buffer *ByteBuffer, fieldType []byte) error {
+	refId, err := f.refResolver.TryPreserveRefId(buffer)
+	if err != nil {
+		return err
+	}
+	if refId < int32(NotNullValueFlag) {
+		// null, or a reference to a value which has been read or skipped.
+		return nil
+	}
+	value, err := skipData(f, buffer, fieldType)
+	if err != nil {
+		return err
+	}
+	f.refResolver.SetReadObject(refId, value)
+	return nil
+}
+
+// skipData skips the data of a value written by `writeValue`. Values of other types are read by `readData`, and
+// returned so the references to them can still be read.
+func skipData(f *Fury, buffer *ByteBuffer, fieldType []byte) (reflect.Value, error) {
+	if f.depth++; f.depth > f.config.maxDepth {
+		return reflect.Value{}, &LimitError{Offset: buffer.readerIndex, Limit: "depth", Value: f.depth,
+			Max: f.config.maxDepth}
+	}
+	defer func() {
+		f.depth--
+	}()
+	start := buffer.readerIndex
+	typeId := buffer.ReadInt16()
+	id := typeId
+	if id < 0 {
+		id = -id
+	}
+	isEnum, byName := enumFieldType(fieldType)
+	switch {
+	case id == LIST || id == FURY_SET || id == MAP:
+	case id == FURY_TYPE_TAG:
+		if !isEnum {
+			buffer.readerIndex = start
+			return readSkippedData(f, buffer)
+		}
+	case typeId >= NotSupportCrossLanguage:
+		buffer.readerIndex = start
+		return readSkippedData(f, buffer)
+	}
+	if typeId < 0 || typeId == FURY_TYPE_TAG {
+		// the type tag of enums and the golang type info of named types are skipped without being resolved.
+		if _, err := f.typeResolver.readMetaString(buffer); err != nil {
+			return reflect.Value{}, err
+		}
+	}
+	switch id {
+	case LIST, FURY_SET:
+		elemType, _ := elemFieldTypes(fieldType, id)
+		length := f.readLength(buffer)
+		for i := 0; i < length; i++ {
+			if err := skipReferencable(f, buffer, elemType); err != nil {
+				return reflect.Value{}, err
+			}
+		}
+	case MAP:
+		keyType, valueType := elemFieldTypes(fieldType, id)
+		length := f.readLength(buffer)
+		for i := 0; i < length; i++ {
+			if err := skipReferencable(f, buffer, keyType); err != nil {
+				return reflect.Value{}, err
+			}
+			if err := skipReferencable(f, buffer, valueType); err != nil {
+				return reflect.Value{}, err
+			}
+		}
+	case FURY_TYPE_TAG:
+		if byName {
+			readString(f, buffer)
+		} else {
+			buffer.ReadVarUint32()
+		}
+	default:
+		// named types of basic types, whose data is the same as the basic types of their type ids.
+		type_, err := f.typeResolver.getTypeById(id)
+		if err != nil {
+			return reflect.Value{}, err
+		}
+		serializer, ok := intReadSerializer(typeId, type_)
+		if !ok {
+			if serializer, err = f.typeResolver.getSerializerByType(type_); err != nil {
+				return reflect.Value{}, err
+			}
+		}
+		value := reflect.New(type_).Elem()
+		if err := serializer.Read(f, buffer, type_, value); err != nil {
+			return reflect.Value{}, err
+		}
+		return value, nil
+	}
+	return reflect.Value{}, nil
+}
+
+// readSkippedData reads a skipped value whose type can be known from the data.
+func readSkippedData(f *Fury, buffer *ByteBuffer) (reflect.Value, error) {
+	var value interface{}
+	v := reflect.ValueOf(&value).Elem()
+	if err := f.readData(buffer, v, nil); err != nil {
+		return reflect.Value{}, err
+	}
+	return v.Elem(), nil
+}
+
+// enumFieldType returns whether the encoded `fieldType` is an enum, and whether its values are written by names.
+func enumFieldType(fieldType []byte) (bool, bool) {
+	if fieldType == nil {
+		return false, false
+	}
+	buffer := NewByteBuffer(fieldType)
+	if TypeId(buffer.ReadVarInt32()>>1) != FURY_TYPE_TAG {
+		return false, false
+	}
+	return true, TypeId(buffer.ReadVarInt32()>>1) == STRING
+}
+
+// elemFieldTypes returns the encoded element type of list/set or the key and value types of map if the encoded
+// `fieldType` is of `typeId`, or nil if the types are unknown, such as the elements of a polymorphic field.
+func elemFieldTypes(fieldType []byte, typeId TypeId) ([]byte, []byte) {
+	if fieldType == nil {
+		return nil, nil
+	}
+	buffer := NewByteBuffer(fieldType)
+	if TypeId(buffer.ReadVarInt32()>>1) != typeId {
+		return nil, nil
+	}
+	elemType := fieldType[buffer.ReaderIndex():]
+	if typeId != MAP {
+		return elemType, nil
+	}
+	skipFieldType(buffer)
+	return elemType, fieldType[buffer.ReaderIndex():]
+}
+
+// matchFields returns the local field for every field in `def`, or nil if the field doesn't exist locally or
+// its type is changed, in which case the field value will be skipped.
+func matchFields(fieldsByName map[string]*fieldInfo, def *typeDef) structFieldsInfo {
+	fields := make(structFieldsInfo, len(def.fields))
+	for i, field := range def.fields {
+		if local, ok := fieldsByName[field.name]; ok && bytes.Equal(local.typeBytes, field.fieldType) {
+			fields[i] = local
+		}
+	}
+	return fields
+}
@@ -18,10 +18,15 @@
 package fury
 
 import (
+	"errors"
 	"fmt"
+	"github.com/apache/fury/go/fury/meta"
 	"github.com/stretchr/testify/require"
+	htmltemplate "html/template"
+	"math"
 	"reflect"
 	"testing"
+	"text/template"
 )
 
 func TestTypeResolver(t *testing.T) {
@@ -32,6 +37,8 @@ func TestTypeResolver(t *testing.T) {
 	fmt.Println(reflect.TypeOf(A{}).String())
 	require.Nil(t, typeResolver.RegisterTypeTag(reflect.TypeOf(A{}), "example.A"))
 	require.Error(t, typeResolver.RegisterTypeTag(reflect.TypeOf(A{}), "example.A"))
+	type B struct{}
+	require.Error(t, typeResolver.RegisterTypeTag(reflect.TypeOf(B{}), "example.A"))
 
 	var tests = []struct {
 		type_    reflect.Type
@@ -54,9 +61,124 @@ func TestTypeResolver(t *testing.T) {
 		require.Equal(t, test.typeInfo, typeStr)
 	}
 	for _, test := range tests {
-		type_, typeStr, err := typeResolver.decodeType(test.typeInfo)
+		type_, typeStr, err := typeResolver.decodeType(test.typeInfo, math.MaxInt32)
 		require.Nil(t, err)
 		require.Equal(t, test.typeInfo, typeStr)
 		require.Equal(t, test.type_, type_)
 	}
 }
+
+type Pair[K comparable, V any] struct {
+	Key   K
+	Value V
+}
+
+func TestQualifiedTypeInfo(t *testing.T) {
+	typeResolver := newTypeResolver()
+	const pkgPath = "github.com/apache/fury/go/fury"
+	// both are `template.Template` by `reflect.Type.String()`.
+	textType, htmlType := reflect.TypeOf(template.Template{}), reflect.TypeOf(htmltemplate.Template{})
+	require.Nil(t, typeResolver.RegisterType(textType))
+	require.Nil(t, typeResolver.RegisterType(htmlType))
+	for type_, typeInfo := range map[reflect.Type]string{
+		textType:                              "text/template.Template",
+		reflect.SliceOf(htmlType):             "[]html/template.Template",
+		reflect.TypeOf(Date{}):                pkgPath + ".Date",
+		reflect.TypeOf((*error)(nil)).Elem():  "error",
+		reflect.TypeOf(map[MyInt][2]*Point{}): "map[" + pkgPath + ".MyInt][2]*" + pkgPath + ".Point",
+	} {
+		encoded, err := typeResolver.encodeType(type_)
+		require.Nil(t, err)
+		require.Equal(t, typeInfo, encoded)
+	}
+
+	pairType := reflect.TypeOf(Pair[MyString, []Pair[int, string]]{})
+	require.Nil(t, typeResolver.RegisterType(pairType))
+	require.Nil(t, typeResolver.RegisterType(reflect.TypeOf(Pair[int, string]{})))
+	for _, type_ := range []reflect.Type{
+		textType,
+		reflect.SliceOf(htmlType),
+		pairType,
+		reflect.TypeOf(map[Pair[int, string]][]Pair[int, string]{}),
+		reflect.TypeOf([]map[string]*Pair[MyString, []Pair[int, string]]{}),
+		reflect.TypeOf([3]map[Pair[int, string]]Pair[MyString, []Pair[int, string]]{}),
+	} {
+		typeInfo, err := typeResolver.encodeType(type_)
+		require.Nil(t, err)
+		decoded, decodedStr, err := typeResolver.decodeType(typeInfo, math.MaxInt32)
+		require.Nil(t, err, typeInfo)
+		require.Equal(t, typeInfo, decodedStr)
+		require.Equal(t, type_, decoded)
+	}
+	for _, typeInfo := range []string{"[-1]int", "[3int", "map[[]int]int", "map[int", "example.com/model.Event",
+		"[]" + pkgPath + ".Pair[int,string"} {
+		_, _, err := typeResolver.decodeType(typeInfo, math.MaxInt32)
+		require.Error(t, err, typeInfo)
+	}
+	for _, typeInfo := range []string{"[7]int", "[2][4]int", "[]map[int][9223372036854775807]int"} {
+		_, _, err := typeResolver.decodeType(typeInfo, 6)
+		var lengthErr *arrayLengthError
+		require.True(t, errors.As(err, &lengthErr), typeInfo)
+	}
+
+	fury := NewFury(WithLanguage(GO))
+	require.Nil(t, fury.RegisterType(Pair[int, string]{}))
+	serde(t, fury, []interface{}{[]Pair[int, string]{{1, "a"}}, map[string]Pair[int, string]{"k": {2, "b"}}})
+}
+
+func TestMetaString(t *testing.T) {
+	writer, reader := newTypeResolver(), newTypeResolver()
+	buffer := NewByteBuffer(nil)
+	strs := []string{"example.foo", "example.Foo", "", "Foo1", "[]int32", "example.model.events.user_created",
+		"github.com/apache/fury/go/fury.Date", "example.foo"}
+	for _, str := range strs {
+		require.Nil(t, writer.writeMetaString(buffer, str))
+	}
+	for _, str := range strs {
+		newStr, err := reader.readMetaString(buffer)
+		require.Nil(t, err)
+		require.Equal(t, str, newStr)
+	}
+	// lower case tags take 5 bits per char.
+	buffer = NewByteBuffer(nil)
+	require.Nil(t, newTypeResolver().writeMetaString(buffer, "example.foo"))
+	require.Equal(t, []byte{byte(7 << 1), byte(meta.LOWER_SPECIAL)}, buffer.GetByteSlice(0, 2))
+	require.Equal(t, 2+7, buffer.WriterIndex())
+
+	// meta strings written as raw UTF-8 can still be read.
+	buffer = NewByteBuffer(nil)
+	buffer.WriteVarInt32(int32(len("example.Foo") << 1))
+	buffer.WriteByte_(byte(meta.UTF_8))
+	buffer.WriteBinary([]byte("example.Foo"))
+	str, err := newTypeResolver().readMetaString(buffer)
+	require.Nil(t, err)
+	require.Equal(t, "example.Foo", str)
+	// `|` must be followed by the lower case char to be escaped.
+	data, err := typeMetaStringEncoder.EncodeWithEncoding("a|", meta.LOWER_SPECIAL)
+	require.Nil(t, err)
+	buffer = NewByteBuffer(nil)
+	buffer.WriteVarInt32(int32(len(data.GetEncodedBytes()) << 1))
+	buffer.WriteByte_(byte(meta.ALL_TO_LOWER_SPECIAL))
+	buffer.WriteBinary(data.GetEncodedBytes())
+	_, err = newTypeResolver().readMetaString(buffer)
+	require.ErrorIs(t, err, ErrInvalidData)
+}
+
+func TestFieldNameEncoding(t *testing.T) {
+	for name, encoding := range map[string]meta.Encoding{
+		"user_id":     meta.ALL_TO_LOWER_SPECIAL,
+		"userId":      meta.ALL_TO_LOWER_SPECIAL,
+		"Name":        meta.ALL_TO_LOWER_SPECIAL,
+		"address2":    meta.LOWER_UPPER_DIGIT_SPECIAL,
+		"a|b":         meta.UTF_8,
+		"名字":          meta.UTF_8,
+		"HTTPRequest": meta.LOWER_UPPER_DIGIT_SPECIAL,
+	} {
+		data, index, err := encodeFieldName(name)
+		require.Nil(t, err)
+		require.Equal(t, encoding, fieldNameEncodings[index], name)
+		decoded, err := decodeMetaString(fieldNameDecoder, data, fieldNameEncodings[index])
+		require.Nil(t, err)
+		require.Equal(t, name, decoded)
+	}
+}